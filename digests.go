@@ -0,0 +1,115 @@
+package manifestival
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/manifestival/manifestival/internal/images"
+	"github.com/manifestival/manifestival/internal/workloads"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// ImageResolver resolves an image reference, e.g. nginx:1.25, to the
+// digest of its content, e.g. sha256:0123..., typically by querying
+// its registry
+type ImageResolver interface {
+	Resolve(image string) (digest string, err error)
+}
+
+// ImageResolverFunc is an ImageResolver implemented by a function
+type ImageResolverFunc func(image string) (string, error)
+
+// Resolve calls f(image)
+func (f ImageResolverFunc) Resolve(image string) (string, error) {
+	return f(image)
+}
+
+// ImageDigests is an ImageResolver for offline use that maps image
+// references to their digests. References are compared by their
+// normalized repositories and tags, so nginx:1.25 and
+// docker.io/library/nginx:1.25 are equivalent, and a reference
+// without a tag implies latest.
+type ImageDigests map[string]string
+
+// Resolve returns the digest of the image, or an error if there is
+// none in the map, or equivalent references map to different digests
+func (d ImageDigests) Resolve(image string) (string, error) {
+	key := imageKey(image)
+	refs := []string{}
+	for ref := range d {
+		if imageKey(ref) == key {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return "", fmt.Errorf("no digest for image %s", image)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs[1:] {
+		if d[ref] != d[refs[0]] {
+			return "", fmt.Errorf("conflicting digests for image %s: %s and %s", image, refs[0], ref)
+		}
+	}
+	return d[refs[0]], nil
+}
+
+// ImageDigestsFrom reads ImageDigests from a YAML or JSON file mapping
+// image references to their digests, e.g. `nginx:1.25: sha256:...`
+func ImageDigestsFrom(path string) (ImageDigests, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	result := ImageDigests{}
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return result, nil
+}
+
+// imageKey identifies the repository and tag of an image
+func imageKey(image string) string {
+	ref := images.Parse(image)
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+	return images.Normalize(ref.Repository) + ":" + ref.Tag
+}
+
+// PinImages creates a Transformer which replaces the tags of the
+// images of workloads with digests from the resolver, e.g. nginx:1.25
+// becomes nginx@sha256:0123.... Each image is resolved only once.
+func PinImages(resolver ImageResolver) Transformer {
+	resolved := map[string]string{}
+	return func(u *unstructured.Unstructured) error {
+		spec, ok := workloads.PodSpec(u)
+		if !ok {
+			return nil
+		}
+		for _, container := range workloads.Containers(spec) {
+			image, _ := container["image"].(string)
+			ref := images.Parse(image)
+			if image == "" || ref.Digest != "" {
+				continue
+			}
+			digest, ok := resolved[image]
+			if !ok {
+				var err error
+				if digest, err = resolver.Resolve(image); err != nil {
+					return fmt.Errorf("%s %s: unable to resolve image %s: %w", u.GetKind(), u.GetName(), image, err)
+				}
+				digest = strings.TrimPrefix(digest, "@")
+				if !images.IsDigest(digest) {
+					return fmt.Errorf("%s %s: invalid digest %q for image %s", u.GetKind(), u.GetName(), digest, image)
+				}
+				resolved[image] = digest
+			}
+			ref.Tag, ref.Digest = "", digest
+			container["image"] = ref.String()
+		}
+		return nil
+	}
+}
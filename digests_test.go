@@ -0,0 +1,87 @@
+package manifestival_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestPinImages(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digests, err := ImageDigestsFrom("testdata/images/digests.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := m.Transform(PinImages(digests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha := func(c string) string {
+		return "@sha256:" + strings.Repeat(c, 64)
+	}
+	want := map[string]string{
+		"Pod/init":           "busybox" + sha("1"),
+		"Pod/app":            "nginx" + sha("2"),
+		"Pod/debug":          "docker.io/library/busybox@" + digest,
+		"Deployment/app":     "gcr.io/project/app" + sha("4"),
+		"Deployment/sidecar": "gcr.io/project/sidecar" + sha("5"),
+		"StatefulSet/db":     "postgres" + sha("6"),
+		"DaemonSet/agent":    "registry.local:5000/agent" + sha("7"),
+		"ReplicaSet/app":     "nginx" + sha("3"),
+		"Job/migrate":        "gcr.io/project/migrate" + sha("8"),
+		"CronJob/backup":     "gcr.io/project/backup" + sha("9"),
+	}
+	if got := containerImages(pinned.Resources()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if cm := pinned.Filter(ByKind("ConfigMap")).Resources()[0]; cm.Object["data"].(map[string]interface{})["image"] != "nginx:1.25" {
+		t.Error("Expected ConfigMap to be unchanged")
+	}
+
+	// Each image is resolved once
+	calls := map[string]int{}
+	counting := ImageResolverFunc(func(image string) (string, error) {
+		calls[image]++
+		return digests.Resolve(image)
+	})
+	if _, err := m.Transform(PinImages(counting)); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, calls["nginx:1.25"], 1)
+	assert(t, len(calls), 9)
+
+	// Every image must be resolved
+	delete(digests, "postgres:15")
+	if _, err := m.Transform(PinImages(digests)); err == nil || !strings.Contains(err.Error(), "postgres:15") {
+		t.Errorf("Expected an error resolving postgres:15, got %v", err)
+	}
+	failing := ImageResolverFunc(func(string) (string, error) {
+		return "", errors.New("registry unavailable")
+	})
+	if _, err := m.Transform(PinImages(failing)); err == nil {
+		t.Error("Expected an error from the resolver")
+	}
+	invalid := ImageResolverFunc(func(string) (string, error) {
+		return "latest", nil
+	})
+	if _, err := m.Transform(PinImages(invalid)); err == nil {
+		t.Error("Expected an error for an invalid digest")
+	}
+	equivalent := ImageDigests{"nginx": digest, "docker.io/library/nginx:latest": digest}
+	if got, err := equivalent.Resolve("nginx"); err != nil || got != digest {
+		t.Errorf("Resolve() = %q, %v, want %q", got, err, digest)
+	}
+	equivalent["docker.io/library/nginx:latest"] = "sha256:" + strings.Repeat("a", 64)
+	if _, err := equivalent.Resolve("nginx"); err == nil || !strings.Contains(err.Error(), "conflicting") {
+		t.Errorf("Expected an error for conflicting digests, got %v", err)
+	}
+	if _, err := ImageDigestsFrom("testdata/images/missing.yaml"); err == nil {
+		t.Error("Expected an error reading a missing file")
+	}
+}
@@ -0,0 +1,114 @@
+package manifestival
+
+import (
+	"os"
+
+	"github.com/manifestival/manifestival/internal/sources"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// EnvOption configures the substitution of environment variables by
+// EnvSubst and SubstituteEnv
+type EnvOption func(*envSubst)
+
+// StrictEnv causes an error when a referenced variable is undefined
+// and no default is provided
+var StrictEnv EnvOption = func(e *envSubst) {
+	e.strict = true
+}
+
+// EnvLookup overrides os.LookupEnv as the source of variable values
+func EnvLookup(fn func(string) (string, bool)) EnvOption {
+	return func(e *envSubst) {
+		e.lookup = fn
+	}
+}
+
+// EnvSubst replaces ${VAR} and ${VAR:-default} references in the
+// content of src with the values of environment variables before
+// parsing it. Use $${VAR} for a literal ${VAR}.
+func EnvSubst(src Source, opts ...EnvOption) Source {
+	e := newEnvSubst(opts)
+	e.src = src
+	return e
+}
+
+// SubstituteEnv creates a Transformer which replaces ${VAR} and
+// ${VAR:-default} references in every string field of a resource
+// with the values of environment variables.
+func SubstituteEnv(opts ...EnvOption) Transformer {
+	e := newEnvSubst(opts)
+	return func(u *unstructured.Unstructured) error {
+		obj, err := e.walk(u.Object)
+		if err != nil {
+			return err
+		}
+		u.Object = obj.(map[string]interface{})
+		return nil
+	}
+}
+
+var _ RawSource = &envSubst{} // see EnvSubst(Source, ...EnvOption)
+
+type envSubst struct {
+	src    Source
+	strict bool
+	lookup func(string) (string, bool)
+}
+
+func newEnvSubst(opts []EnvOption) *envSubst {
+	result := &envSubst{lookup: os.LookupEnv}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
+
+func (e *envSubst) Parse() ([]unstructured.Unstructured, error) {
+	docs, err := e.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return sources.DecodeAll(docs)
+}
+
+func (e *envSubst) Raw() ([][]byte, error) {
+	docs, err := raw(e.src)
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		s, err := e.expand(string(doc))
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = []byte(s)
+	}
+	return docs, nil
+}
+
+func (e *envSubst) expand(s string) (string, error) {
+	return sources.ExpandEnv(s, e.lookup, e.strict)
+}
+
+// walk expands the strings within an unstructured object
+func (e *envSubst) walk(v interface{}) (interface{}, error) {
+	var err error
+	switch x := v.(type) {
+	case string:
+		return e.expand(x)
+	case map[string]interface{}:
+		for k, val := range x {
+			if x[k], err = e.walk(val); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, val := range x {
+			if x[i], err = e.walk(val); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,86 @@
+package manifestival_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+const envManifest = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ${NAME}
+data:
+  domain: ${DOMAIN:-example.com}
+  script: echo $${HOME}
+`
+
+func TestEnvSubst(t *testing.T) {
+	os.Setenv("NAME", "foo")
+	defer os.Unsetenv("NAME")
+	m, err := ManifestFrom(EnvSubst(Reader(strings.NewReader(envManifest))))
+	if err != nil {
+		t.Fatalf("EnvSubst returned: %v", err)
+	}
+	u := m.Resources()[0]
+	if u.GetName() != "foo" {
+		t.Errorf("Expected name 'foo', got %q", u.GetName())
+	}
+	data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+	if data["domain"] != "example.com" {
+		t.Errorf("Expected default domain, got %q", data["domain"])
+	}
+	if data["script"] != "echo ${HOME}" {
+		t.Errorf("Expected escaped reference, got %q", data["script"])
+	}
+}
+
+func TestEnvSubstStrict(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+	src := EnvSubst(Reader(strings.NewReader(envManifest)), StrictEnv, EnvLookup(lookup))
+	if _, err := ManifestFrom(src); err == nil {
+		t.Error("Expected an error for undefined NAME")
+	}
+}
+
+func TestSubstituteEnv(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "REGISTRY" {
+			return "gcr.io", true
+		}
+		return "", false
+	}
+	u := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"containers": []interface{}{
+				map[string]interface{}{"image": "${REGISTRY}/web:${TAG:-latest}"},
+			},
+		},
+	}}
+	m, _ := ManifestFrom(Slice([]unstructured.Unstructured{u}))
+	m, err := m.Transform(SubstituteEnv(EnvLookup(lookup)))
+	if err != nil {
+		t.Fatalf("SubstituteEnv returned: %v", err)
+	}
+	containers, _, _ := unstructured.NestedSlice(m.Resources()[0].Object, "spec", "containers")
+	if image := containers[0].(map[string]interface{})["image"]; image != "gcr.io/web:latest" {
+		t.Errorf("Expected 'gcr.io/web:latest', got %q", image)
+	}
+	if _, err := m.Transform(SubstituteEnv(StrictEnv, EnvLookup(lookup))); err != nil {
+		t.Errorf("Expected no error when all variables resolve, got %v", err)
+	}
+	u.SetName("${UNDEFINED}")
+	m, _ = ManifestFrom(Slice([]unstructured.Unstructured{u}))
+	if _, err := m.Transform(SubstituteEnv(StrictEnv, EnvLookup(lookup))); err == nil {
+		t.Error("Expected an error for undefined variable in strict mode")
+	}
+}
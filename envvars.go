@@ -0,0 +1,134 @@
+package manifestival
+
+import (
+	"github.com/manifestival/manifestival/internal/workloads"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EnvVars modifies the environment variables of containers. It
+// applies to all the containers of a workload unless Containers names
+// some of them.
+type EnvVars struct {
+	// Set replaces the variables of the same names, or appends them
+	Set []corev1.EnvVar
+	// Default appends the variables not already defined
+	Default []corev1.EnvVar
+	// Remove removes the variables of these names
+	Remove     []string
+	Containers []string
+}
+
+// InjectEnv creates a Transformer which modifies the environment
+// variables of the containers of workloads for which no Predicate
+// returns false. A replacement takes the place of the first variable
+// of its name, and any others of that name are removed.
+func InjectEnv(env EnvVars, preds ...Predicate) Transformer {
+	pred := All(preds...)
+	set, err := envList(env.Set)
+	defaults, defaultsErr := envList(env.Default)
+	if err == nil {
+		err = defaultsErr
+	}
+	return func(u *unstructured.Unstructured) error {
+		if err != nil {
+			return err
+		}
+		spec, ok := workloads.PodSpec(u)
+		if !ok || !pred(u) {
+			return nil
+		}
+		for _, container := range workloads.Containers(spec) {
+			if !env.selects(container) {
+				continue
+			}
+			vars, _ := container["env"].([]interface{})
+			vars = removeEnv(vars, env.Remove)
+			for _, v := range set {
+				vars = setEnv(vars, v, true)
+			}
+			for _, v := range defaults {
+				vars = setEnv(vars, v, false)
+			}
+			if len(vars) > 0 {
+				container["env"] = vars
+			} else {
+				delete(container, "env")
+			}
+		}
+		return nil
+	}
+}
+
+func (e EnvVars) selects(container map[string]interface{}) bool {
+	if len(e.Containers) == 0 {
+		return true
+	}
+	for _, name := range e.Containers {
+		if container["name"] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// envList converts variables to their unstructured form
+func envList(vars []corev1.EnvVar) ([]map[string]interface{}, error) {
+	result := []map[string]interface{}{}
+	for i := range vars {
+		v, err := toUnstructured(&vars[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// setEnv adds v to vars, replacing the first variable of the same
+// name and removing any others, if override is true, or leaving vars
+// alone if it already defines the name otherwise
+func setEnv(vars []interface{}, v map[string]interface{}, override bool) []interface{} {
+	result := make([]interface{}, 0, len(vars)+1)
+	found := false
+	for _, e := range vars {
+		m, _ := e.(map[string]interface{})
+		if m == nil || m["name"] != v["name"] {
+			result = append(result, e)
+			continue
+		}
+		if !override {
+			return vars
+		}
+		if !found {
+			result = append(result, runtime.DeepCopyJSON(v))
+			found = true
+		}
+	}
+	if !found {
+		result = append(result, runtime.DeepCopyJSON(v))
+	}
+	return result
+}
+
+// removeEnv returns vars without the variables with the given names
+func removeEnv(vars []interface{}, names []string) []interface{} {
+	if len(names) == 0 {
+		return vars
+	}
+	removed := map[string]bool{}
+	for _, name := range names {
+		removed[name] = true
+	}
+	result := []interface{}{}
+	for _, e := range vars {
+		if m, ok := e.(map[string]interface{}); ok {
+			if name, _ := m["name"].(string); removed[name] {
+				continue
+			}
+		}
+		result = append(result, e)
+	}
+	return result
+}
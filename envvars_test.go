@@ -0,0 +1,141 @@
+package manifestival_test
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestInjectEnv(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Transform(SetField(".spec.template.spec.containers[0].env", []interface{}{
+		map[string]interface{}{"name": "LOG_LEVEL", "value": "info"},
+		map[string]interface{}{"name": "MODE", "value": "a"},
+		map[string]interface{}{"name": "OLD", "value": "x"},
+		map[string]interface{}{"name": "MODE", "value": "b"},
+	}, ByKind("Deployment")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Transform(InjectEnv(EnvVars{
+		Set: []corev1.EnvVar{
+			{Name: "MODE", Value: "prod"},
+			{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+					Key:                  "password",
+				},
+			}},
+		},
+		Default: []corev1.EnvVar{
+			{Name: "LOG_LEVEL", Value: "debug"},
+			{Name: "NO_PROXY", Value: ".cluster.local"},
+		},
+		Remove: []string{"OLD"},
+	}, Not(ByKind("Job"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := map[string]interface{}{
+		"name": "PASSWORD",
+		"valueFrom": map[string]interface{}{
+			"secretKeyRef": map[string]interface{}{"name": "db", "key": "password"},
+		},
+	}
+	noProxy := map[string]interface{}{"name": "NO_PROXY", "value": ".cluster.local"}
+	tests := []struct {
+		kind      string
+		container string
+		want      interface{}
+	}{{
+		kind:      "Deployment",
+		container: "app",
+		want: []interface{}{
+			map[string]interface{}{"name": "LOG_LEVEL", "value": "info"},
+			map[string]interface{}{"name": "MODE", "value": "prod"},
+			password,
+			noProxy,
+		},
+	}, {
+		kind:      "Deployment",
+		container: "sidecar",
+		want: []interface{}{
+			map[string]interface{}{"name": "MODE", "value": "prod"},
+			password,
+			map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+			noProxy,
+		},
+	}, {
+		kind:      "Pod",
+		container: "debug",
+		want: []interface{}{
+			map[string]interface{}{"name": "MODE", "value": "prod"},
+			password,
+			map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+			noProxy,
+		},
+	}, {
+		kind:      "Job",
+		container: "migrate",
+		want:      nil,
+	}}
+	for _, test := range tests {
+		u := m.Filter(ByKind(test.kind)).Resources()[0]
+		got := containerEnv(t, &u, test.container)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %s env = %v, want %v", test.kind, test.container, got, test.want)
+		}
+	}
+
+	// Applying it again changes nothing
+	u := m.Filter(ByKind("Deployment")).Resources()[0]
+	before := containerEnv(t, &u, "app")
+	again, err := m.Transform(InjectEnv(EnvVars{Set: []corev1.EnvVar{{Name: "MODE", Value: "prod"}}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u = again.Filter(ByKind("Deployment")).Resources()[0]
+	if got := containerEnv(t, &u, "app"); !reflect.DeepEqual(got, before) {
+		t.Errorf("env = %v, want %v", got, before)
+	}
+
+	// Only the named containers are modified, and removing every
+	// variable removes the env field
+	m, err = m.Transform(InjectEnv(EnvVars{
+		Remove:     []string{"LOG_LEVEL", "MODE", "PASSWORD", "NO_PROXY"},
+		Containers: []string{"sidecar"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u = m.Filter(ByKind("Deployment")).Resources()[0]
+	if got := containerEnv(t, &u, "sidecar"); got != nil {
+		t.Errorf("sidecar env = %v, want none", got)
+	}
+	if got := containerEnv(t, &u, "app"); len(got.([]interface{})) != 4 {
+		t.Errorf("app env = %v, want it unchanged", got)
+	}
+}
+
+func containerEnv(t *testing.T, u *unstructured.Unstructured, name string) interface{} {
+	t.Helper()
+	spec := podSpec(t, u)
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _, _ := unstructured.NestedSlice(spec, field)
+		for _, c := range containers {
+			if c := c.(map[string]interface{}); c["name"] == name {
+				return c["env"]
+			}
+		}
+	}
+	t.Fatalf("%s %s has no container %s", u.GetKind(), u.GetName(), name)
+	return nil
+}
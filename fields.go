@@ -0,0 +1,68 @@
+package manifestival
+
+import (
+	"fmt"
+
+	"github.com/manifestival/manifestival/internal/fieldpath"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SetField creates a Transformer which sets the field at path to
+// value in the resources for which no Predicate returns false,
+// creating any missing objects leading to it. The path is either a
+// JSON pointer, e.g. /spec/replicas, or a JSONPath expression, e.g.
+// .spec.template.spec.containers[?(@.name=="app")].image, which may
+// match several fields. The value may be anything that can be
+// marshaled as JSON.
+func SetField(path string, value interface{}, preds ...Predicate) Transformer {
+	return updateField(path, value, preds, func(p *fieldpath.Path, obj map[string]interface{}, v interface{}) error {
+		return p.Set(obj, v)
+	})
+}
+
+// DeleteField creates a Transformer which removes the fields or list
+// elements at path, as understood by SetField, from the resources for
+// which no Predicate returns false. Fields that don't exist are
+// ignored.
+func DeleteField(path string, preds ...Predicate) Transformer {
+	return updateField(path, nil, preds, func(p *fieldpath.Path, obj map[string]interface{}, _ interface{}) error {
+		return p.Delete(obj)
+	})
+}
+
+// AppendField creates a Transformer which appends value to the lists
+// at path, as understood by SetField, in the resources for which no
+// Predicate returns false, creating them if they don't exist. If
+// value is a slice, each of its elements is appended.
+func AppendField(path string, value interface{}, preds ...Predicate) Transformer {
+	return updateField(path, value, preds, func(p *fieldpath.Path, obj map[string]interface{}, v interface{}) error {
+		if values, ok := v.([]interface{}); ok {
+			return p.Append(obj, values...)
+		}
+		return p.Append(obj, v)
+	})
+}
+
+// updateField parses path and value once, returning a Transformer
+// that reports any error doing so rather than updating anything
+func updateField(path string, value interface{}, preds []Predicate, update func(*fieldpath.Path, map[string]interface{}, interface{}) error) Transformer {
+	p, err := fieldpath.Parse(path)
+	if err == nil {
+		if value, err = fieldpath.JSONValue(value); err != nil {
+			err = fmt.Errorf("invalid value for %s: %w", path, err)
+		}
+	}
+	pred := All(preds...)
+	return func(u *unstructured.Unstructured) error {
+		if err != nil {
+			return err
+		}
+		if !pred(u) {
+			return nil
+		}
+		if err := update(p, u.Object, value); err != nil {
+			return fmt.Errorf("%s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		return nil
+	}
+}
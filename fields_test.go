@@ -0,0 +1,93 @@
+package manifestival_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestFields(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	toleration := map[string]string{"key": "dedicated", "operator": "Exists"}
+	m, err = m.Transform(
+		SetField("/spec/replicas", 3, ByKind("Deployment")),
+		SetField(`.spec.template.spec.containers[?(@.name=="sidecar")].imagePullPolicy`, "Always"),
+		SetField("{.metadata.labels['app.kubernetes.io/part-of']}", "shop", ByName("config")),
+		DeleteField(".spec.selector", ByKind("Deployment")),
+		AppendField(".spec.template.spec.tolerations", []interface{}{toleration}, ByKind("DaemonSet")),
+		AppendField("/data/-", "ignored", Nothing),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := m.Filter(ByKind("Deployment")).Resources()[0]
+	if replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("replicas = %d, want 3", replicas)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(deployment.Object, "spec", "selector"); found {
+		t.Error("Expected selector to be deleted")
+	}
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	for _, c := range containers {
+		container := c.(map[string]interface{})
+		_, found := container["imagePullPolicy"]
+		if want := container["name"] == "sidecar"; found != want {
+			t.Errorf("%s imagePullPolicy set = %v, want %v", container["name"], found, want)
+		}
+	}
+	if replicas, found, _ := unstructured.NestedInt64(m.Filter(ByKind("StatefulSet")).Resources()[0].Object, "spec", "replicas"); found {
+		t.Errorf("Expected StatefulSet replicas to be unset, got %d", replicas)
+	}
+	if got := m.Filter(ByName("config")).Resources()[0].GetLabels()["app.kubernetes.io/part-of"]; got != "shop" {
+		t.Errorf("part-of label = %q", got)
+	}
+	daemonset := m.Filter(ByKind("DaemonSet")).Resources()[0]
+	tolerations, _, _ := unstructured.NestedSlice(daemonset.Object, "spec", "template", "spec", "tolerations")
+	want := []interface{}{map[string]interface{}{"key": "dedicated", "operator": "Exists"}}
+	if !reflect.DeepEqual(tolerations, want) {
+		t.Errorf("tolerations = %v, want %v", tolerations, want)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		transformer Transformer
+		want        string
+	}{{
+		name:        "invalid path",
+		transformer: SetField(".spec..replicas", 1, Nothing),
+		want:        `invalid path ".spec..replicas"`,
+	}, {
+		name:        "invalid value",
+		transformer: SetField(".spec.replicas", func() {}),
+		want:        "invalid value for .spec.replicas",
+	}, {
+		name:        "type mismatch",
+		transformer: AppendField("/metadata/name", "x", ByKind("ConfigMap")),
+		want:        "ConfigMap config: /metadata/name is not a list",
+	}, {
+		name:        "missing element",
+		transformer: SetField(".spec.template.spec.containers[1].image", "x", ByKind("StatefulSet")),
+		want:        "StatefulSet statefulset: .spec.template.spec.containers[1].image: .spec.template.spec.containers has no element 1",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := m.Transform(test.transformer)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Expected error containing %q, got %v", test.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package manifestival
+
+import (
+	"strings"
+
+	"github.com/manifestival/manifestival/internal/kustomize"
+	"github.com/manifestival/manifestival/internal/refs"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Generator describes a ConfigMap or Secret built from files,
+// literals and env files, much like kustomize's configMapGenerator
+// and secretGenerator. Unless DisableNameSuffixHash is set, a hash of
+// its content is appended to its name, so that workloads referring to
+// it are rolled out whenever it changes, once InjectGeneratedNames
+// has updated their references.
+type Generator struct {
+	Name      string
+	Namespace string
+	// Files are added with their base names as keys, or with the
+	// keys given as KEY=PATH
+	Files []string
+	// Literals are KEY=VALUE pairs
+	Literals []string
+	// EnvFiles contain KEY=VALUE lines, ignoring blank lines and
+	// comments
+	EnvFiles []string
+	// Type is the type of a Secret, Opaque by default
+	Type string
+
+	Labels                map[string]string
+	Annotations           map[string]string
+	DisableNameSuffixHash bool
+	Immutable             bool
+}
+
+// GenerateConfigMap is a Source of the ConfigMap described by g.
+// Values that aren't valid UTF-8 are stored as binaryData.
+func GenerateConfigMap(g Generator) Source {
+	return generated{g, "ConfigMap"}
+}
+
+// GenerateSecret is a Source of the Secret described by g
+func GenerateSecret(g Generator) Source {
+	return generated{g, "Secret"}
+}
+
+// InjectGeneratedNames creates a Transformer which updates references
+// to the ConfigMaps and Secrets in generated to their hashed names.
+func InjectGeneratedNames(generated Manifest) Transformer {
+	// Those without a namespace are kept apart, so that they match
+	// references from any namespace, but only they do
+	namespaced, unnamespaced := refs.Renames{}, refs.Renames{}
+	for _, u := range generated.resources {
+		name, ok := unhashedName(&u)
+		switch {
+		case !ok:
+		case u.GetNamespace() == "":
+			unnamespaced.Add(u.GetKind(), "", name, u.GetName())
+		default:
+			namespaced.Add(u.GetKind(), u.GetNamespace(), name, u.GetName())
+		}
+	}
+	rename := func(kind, namespace, name string) (string, bool) {
+		if newName, ok := namespaced.Rename(kind, namespace, name); ok {
+			return newName, ok
+		}
+		return unnamespaced.Rename(kind, "", name)
+	}
+	return func(u *unstructured.Unstructured) error {
+		refs.Update(u, rename)
+		return nil
+	}
+}
+
+type generated struct {
+	g    Generator
+	kind string
+}
+
+var _ Source = generated{}
+
+func (g generated) Parse() ([]unstructured.Unstructured, error) {
+	generator := kustomize.Generator{
+		Name:      g.g.Name,
+		Namespace: g.g.Namespace,
+		Type:      g.g.Type,
+		Files:     g.g.Files,
+		Literals:  g.g.Literals,
+		Envs:      g.g.EnvFiles,
+		Options: &kustomize.GeneratorOptions{
+			Labels:                g.g.Labels,
+			Annotations:           g.g.Annotations,
+			DisableNameSuffixHash: g.g.DisableNameSuffixHash,
+			Immutable:             g.g.Immutable,
+		},
+	}
+	var u *unstructured.Unstructured
+	var err error
+	if g.kind == "Secret" {
+		u, err = generator.Secret("", nil)
+	} else {
+		u, err = generator.ConfigMap("", nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := appendHash(u); err != nil {
+		return nil, err
+	}
+	return []unstructured.Unstructured{*u}, nil
+}
+
+// appendHash appends a hash of the content of a generated resource
+// to its name, if annotated as needing one, and removes the
+// annotation. It returns true if the resource was renamed.
+func appendHash(u *unstructured.Unstructured) (bool, error) {
+	annotations := u.GetAnnotations()
+	if _, ok := annotations[kustomize.NeedsHash]; !ok {
+		return false, nil
+	}
+	delete(annotations, kustomize.NeedsHash)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	u.SetAnnotations(annotations)
+	hash, err := kustomize.Hash(u)
+	if err != nil {
+		return false, err
+	}
+	u.SetName(u.GetName() + "-" + hash)
+	return true, nil
+}
+
+// unhashedName returns the name of a ConfigMap or Secret without the
+// hash appended by appendHash, if it has one matching its content
+func unhashedName(u *unstructured.Unstructured) (string, bool) {
+	if u.GetKind() != "ConfigMap" && u.GetKind() != "Secret" {
+		return "", false
+	}
+	i := strings.LastIndex(u.GetName(), "-")
+	if i <= 0 {
+		return "", false
+	}
+	original := u.DeepCopy()
+	original.SetName(u.GetName()[:i])
+	if hash, err := kustomize.Hash(original); err != nil || hash != u.GetName()[i+1:] {
+		return "", false
+	}
+	return original.GetName(), true
+}
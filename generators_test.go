@@ -0,0 +1,135 @@
+package manifestival_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestGenerateConfigMap(t *testing.T) {
+	m, err := ManifestFrom(GenerateConfigMap(Generator{
+		Name:     "web-config",
+		Literals: []string{"LOG_LEVEL=info"},
+		Files:    []string{"testdata/kustomize/base/nginx.conf"},
+		Labels:   map[string]string{"app": "web"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := m.Resources()[0]
+	// The name should match the one kustomize generates
+	k, err := ManifestFrom(Kustomize("testdata/kustomize/base"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := k.Filter(ByKind("ConfigMap")).Resources()[0].GetName(); cm.GetName() != want {
+		t.Errorf("name = %q, want %q", cm.GetName(), want)
+	}
+	data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+	if data["LOG_LEVEL"] != "info" || !strings.Contains(data["nginx.conf"], "listen 80") {
+		t.Errorf("Unexpected data %v", data)
+	}
+	if len(cm.GetAnnotations()) != 0 || cm.GetLabels()["app"] != "web" {
+		t.Errorf("Unexpected metadata %v %v", cm.GetLabels(), cm.GetAnnotations())
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	m, err := ManifestFrom(GenerateSecret(Generator{
+		Name:      "app",
+		Namespace: "system",
+		EnvFiles:  []string{"testdata/generators/app.env"},
+		Type:      "example.com/settings",
+		Immutable: true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := m.Resources()[0]
+	if !strings.HasPrefix(secret.GetName(), "app-") || len(secret.GetName()) != len("app-")+10 {
+		t.Errorf("Expected a hashed name, got %q", secret.GetName())
+	}
+	if secret.GetNamespace() != "system" || secret.Object["type"] != "example.com/settings" || secret.Object["immutable"] != true {
+		t.Errorf("Unexpected secret %v", secret.Object)
+	}
+	data, _, _ := unstructured.NestedStringMap(secret.Object, "data")
+	for k, want := range map[string]string{"LOG_LEVEL": "debug", "FEATURES": "a,b"} {
+		if got, _ := base64.StdEncoding.DecodeString(data[k]); string(got) != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+
+	unhashed, err := ManifestFrom(GenerateSecret(Generator{Name: "app", Literals: []string{"a=b"}, DisableNameSuffixHash: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := unhashed.Resources()[0].GetName(); name != "app" {
+		t.Errorf("Expected name to be unchanged, got %q", name)
+	}
+
+	for _, g := range []Generator{
+		{Name: "missing", Files: []string{"testdata/generators/missing"}},
+		{Name: "invalid", Literals: []string{"no value"}},
+		{Name: "duplicate", Literals: []string{"a=b", "a=c"}},
+	} {
+		if _, err := ManifestFrom(GenerateSecret(g)); err == nil {
+			t.Errorf("Expected an error generating %s", g.Name)
+		}
+	}
+}
+
+func TestInjectGeneratedNames(t *testing.T) {
+	config, err := ManifestFrom(GenerateConfigMap(Generator{Name: "web-config", Literals: []string{"LOG_LEVEL=info"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Not in the namespace of the Deployment referring to web-secret
+	secret, err := ManifestFrom(GenerateSecret(Generator{Name: "web-secret", Namespace: "other", Literals: []string{"token=x"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := config.Append(secret)
+	m, err := ManifestFrom(Path("testdata/kustomize/base/deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Append(generated).Transform(InjectNamespace("default"), InjectGeneratedNames(generated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := config.Resources()[0].GetName()
+	deployment := m.Filter(ByKind("Deployment")).Resources()[0]
+	spec, _, _ := unstructured.NestedMap(deployment.Object, "spec", "template", "spec")
+	envFrom := spec["containers"].([]interface{})[0].(map[string]interface{})["envFrom"].([]interface{})
+	if got := envFrom[0].(map[string]interface{})["configMapRef"].(map[string]interface{})["name"]; got != hashed {
+		t.Errorf("configMapRef = %v, want %s", got, hashed)
+	}
+	if got := envFrom[1].(map[string]interface{})["secretRef"].(map[string]interface{})["name"]; got != "web-secret" {
+		t.Errorf("Expected secret in another namespace to be ignored, got %v", got)
+	}
+	if got := spec["volumes"].([]interface{})[0].(map[string]interface{})["configMap"].(map[string]interface{})["name"]; got != hashed {
+		t.Errorf("volume configMap = %v, want %s", got, hashed)
+	}
+
+	// Changing the content invalidates the hash
+	changed, err := config.Transform(SetField(".data.LOG_LEVEL", "debug"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = ManifestFrom(Path("testdata/kustomize/base/deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err = m.Transform(InjectGeneratedNames(changed)); err != nil {
+		t.Fatal(err)
+	}
+	deployment = m.Filter(ByKind("Deployment")).Resources()[0]
+	volumes, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "volumes")
+	if got := volumes[0].(map[string]interface{})["configMap"].(map[string]interface{})["name"]; got != "web-config" {
+		t.Errorf("Expected reference to be unchanged, got %v", got)
+	}
+}
@@ -0,0 +1,64 @@
+package manifestival
+
+import (
+	"path"
+	"strings"
+
+	"github.com/manifestival/manifestival/internal/git"
+	"github.com/manifestival/manifestival/internal/sources"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Git is a Source comprised of the manifests committed to a local git
+// repository, either bare or with a working tree, at some revision,
+// regardless of what's checked out. The Revision may be a branch, a
+// tag, or a full or abbreviated commit hash, defaulting to HEAD. If
+// Path names a directory, only the YAML and JSON files within it,
+// searched recursively if Recursive is true, are parsed; a file is
+// parsed regardless of its extension, and if it's an archive, the
+// manifests within it are.
+type Git struct {
+	Repository string
+	Revision   string
+	Path       string
+	Recursive  bool
+}
+
+var _ RawSource = Git{}
+
+func (g Git) Parse() ([]unstructured.Unstructured, error) {
+	docs, err := g.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return sources.DecodeAll(docs)
+}
+
+func (g Git) Raw() ([][]byte, error) {
+	repo, err := git.Open(g.Repository)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.Resolve(g.Revision)
+	if err != nil {
+		return nil, err
+	}
+	files, err := repo.Files(commit, g.Path, g.Recursive)
+	if err != nil {
+		return nil, err
+	}
+	// Path named a file rather than a directory
+	if len(files) == 1 && files[0].Path == strings.Trim(path.Clean("/"+g.Path), "/") {
+		if sources.IsArchive(files[0].Path) {
+			return sources.ReadArchive(files[0].Data)
+		}
+		return [][]byte{files[0].Data}, nil
+	}
+	docs := [][]byte{}
+	for _, f := range files {
+		if sources.IsManifest(f.Path) {
+			docs = append(docs, f.Data)
+		}
+	}
+	return docs, nil
+}
@@ -0,0 +1,75 @@
+package manifestival_test
+
+import (
+	"testing"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestGit(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    Git
+		want      []string
+		replicas  int64
+		wantError bool
+	}{{
+		name:     "head",
+		source:   Git{Repository: "internal/git/testdata/repo.git", Path: "config"},
+		want:     []string{"Deployment", "Service"},
+		replicas: 2,
+	}, {
+		name:     "recursive",
+		source:   Git{Repository: "internal/git/testdata/repo.git", Recursive: true},
+		want:     []string{"Deployment", "ConfigMap", "Service"},
+		replicas: 2,
+	}, {
+		name:     "tag",
+		source:   Git{Repository: "internal/git/testdata/repo.git", Revision: "v1.0.0", Path: "config", Recursive: true},
+		want:     []string{"Deployment", "Service"},
+		replicas: 1,
+	}, {
+		name:   "file",
+		source: Git{Repository: "internal/git/testdata/repo.git", Revision: "v1.1.0", Path: "config/extra/configmap.yaml"},
+		want:   []string{"ConfigMap"},
+	}, {
+		name:      "unknown revision",
+		source:    Git{Repository: "internal/git/testdata/repo.git", Revision: "v2.0.0"},
+		wantError: true,
+	}, {
+		name:      "not a repository",
+		source:    Git{Repository: "testdata/tree"},
+		wantError: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := ManifestFrom(test.source)
+			if test.wantError {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			kinds := []string{}
+			for _, u := range m.Resources() {
+				kinds = append(kinds, u.GetKind())
+				if u.GetKind() == "Deployment" {
+					if replicas := u.Object["spec"].(map[string]interface{})["replicas"]; replicas != test.replicas {
+						t.Errorf("Got %v replicas, want %d", replicas, test.replicas)
+					}
+				}
+			}
+			if len(kinds) != len(test.want) {
+				t.Fatalf("Got %v, want %v", kinds, test.want)
+			}
+			for i := range kinds {
+				if kinds[i] != test.want[i] {
+					t.Errorf("Got %v, want %v", kinds, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package manifestival
+
+import (
+	"strings"
+
+	"github.com/manifestival/manifestival/internal/images"
+	"github.com/manifestival/manifestival/internal/workloads"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Image overrides the images of the containers it matches. A
+// container matches if its name equals Container, its image's
+// repository is equivalent to Name, e.g. nginx and
+// docker.io/library/nginx, and its image begins with Prefix,
+// ignoring whichever of those are empty.
+type Image struct {
+	Container string
+	Name      string
+	Prefix    string
+
+	// NewPrefix replaces Prefix, e.g. to use a registry mirror
+	NewPrefix string
+	// NewName replaces the repository, retaining any tag or digest
+	NewName string
+	// NewTag replaces the tag and removes any digest
+	NewTag string
+	// Digest replaces both the tag and any digest
+	Digest string
+}
+
+// InjectImages creates a Transformer which overrides the images of the
+// containers of workloads. Only the first matching image is applied.
+func InjectImages(overrides ...Image) Transformer {
+	return func(u *unstructured.Unstructured) error {
+		spec, ok := workloads.PodSpec(u)
+		if !ok {
+			return nil
+		}
+		for _, container := range workloads.Containers(spec) {
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			for _, override := range overrides {
+				if override.matches(name, image) {
+					container["image"] = override.apply(image)
+					break
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func (i Image) matches(container, image string) bool {
+	if i.Container != "" && i.Container != container {
+		return false
+	}
+	if i.Name != "" && images.Normalize(i.Name) != images.Normalize(images.Parse(image).Repository) {
+		return false
+	}
+	return strings.HasPrefix(image, i.Prefix)
+}
+
+func (i Image) apply(image string) string {
+	if i.NewPrefix != "" {
+		image = i.NewPrefix + strings.TrimPrefix(image, i.Prefix)
+	}
+	ref := images.Parse(image)
+	if i.NewName != "" {
+		ref.Repository = i.NewName
+	}
+	switch {
+	case i.Digest != "":
+		ref.Tag, ref.Digest = "", strings.TrimPrefix(i.Digest, "@")
+	case i.NewTag != "":
+		ref.Tag, ref.Digest = i.NewTag, ""
+	}
+	return ref.String()
+}
@@ -0,0 +1,123 @@
+package manifestival_test
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestInjectImages(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides []Image
+		want      map[string]string
+	}{{
+		name:      "by container",
+		overrides: []Image{{Container: "app", NewTag: "v2"}},
+		want: map[string]string{
+			"Pod/app":        "nginx:v2",
+			"Deployment/app": "gcr.io/project/app:v2",
+			"ReplicaSet/app": "nginx:v2",
+		},
+	}, {
+		name:      "by equivalent name",
+		overrides: []Image{{Name: "docker.io/library/nginx", NewName: "mirror.local/nginx"}},
+		want: map[string]string{
+			"Pod/app":        "mirror.local/nginx:1.25",
+			"ReplicaSet/app": "mirror.local/nginx",
+		},
+	}, {
+		name:      "by container and name",
+		overrides: []Image{{Container: "app", Name: "nginx", Digest: digest}},
+		want: map[string]string{
+			"Pod/app":        "nginx@" + digest,
+			"ReplicaSet/app": "nginx@" + digest,
+		},
+	}, {
+		name:      "registry prefix",
+		overrides: []Image{{Prefix: "gcr.io/project/", NewPrefix: "mirror.local/project/"}},
+		want: map[string]string{
+			"Deployment/app":     "mirror.local/project/app:v1",
+			"Deployment/sidecar": "mirror.local/project/sidecar:v1",
+			"Job/migrate":        "mirror.local/project/migrate:v1",
+			"CronJob/backup":     "mirror.local/project/backup:v1",
+		},
+	}, {
+		name:      "prefix only selects",
+		overrides: []Image{{Prefix: "gcr.io/project/", NewTag: "v2"}},
+		want: map[string]string{
+			"Deployment/app":     "gcr.io/project/app:v2",
+			"Deployment/sidecar": "gcr.io/project/sidecar:v2",
+			"Job/migrate":        "gcr.io/project/migrate:v2",
+			"CronJob/backup":     "gcr.io/project/backup:v2",
+		},
+	}, {
+		name:      "tag replaces digest",
+		overrides: []Image{{Name: "busybox", NewTag: "1.37"}},
+		want: map[string]string{
+			"Pod/init":  "busybox:1.37",
+			"Pod/debug": "docker.io/library/busybox:1.37",
+		},
+	}, {
+		name: "first match wins",
+		overrides: []Image{
+			{Container: "agent", NewTag: "v2"},
+			{Name: "registry.local:5000/agent", NewTag: "v3"},
+			{Name: "postgres", NewName: "bitnami/postgresql"},
+		},
+		want: map[string]string{
+			"DaemonSet/agent": "registry.local:5000/agent:v2",
+			"StatefulSet/db":  "bitnami/postgresql:15",
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := containerImages(m.Resources())
+			if m, err = m.Transform(InjectImages(test.overrides...)); err != nil {
+				t.Fatal(err)
+			}
+			after := containerImages(m.Resources())
+			for k, v := range test.want {
+				before[k] = v
+			}
+			if !reflect.DeepEqual(after, before) {
+				t.Errorf("Got %v, want %v", after, before)
+			}
+			if cm := m.Filter(ByKind("ConfigMap")).Resources()[0]; cm.Object["data"].(map[string]interface{})["image"] != "nginx:1.25" {
+				t.Error("Expected ConfigMap to be unchanged")
+			}
+		})
+	}
+}
+
+// containerImages returns the images in each workload, keyed by kind
+// and container name
+func containerImages(resources []unstructured.Unstructured) map[string]string {
+	result := map[string]string{}
+	for _, u := range resources {
+		var spec map[string]interface{}
+		var found bool
+		for _, path := range [][]string{{"spec"}, {"spec", "template", "spec"}, {"spec", "jobTemplate", "spec", "template", "spec"}} {
+			if spec, found, _ = unstructured.NestedMap(u.Object, path...); found && (spec["containers"] != nil) {
+				break
+			}
+		}
+		for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			containers, _, _ := unstructured.NestedSlice(spec, field)
+			for _, c := range containers {
+				c := c.(map[string]interface{})
+				result[u.GetKind()+"/"+c["name"].(string)] = c["image"].(string)
+			}
+		}
+	}
+	return result
+}
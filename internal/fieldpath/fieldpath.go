@@ -0,0 +1,220 @@
+// Package fieldpath locates fields within unstructured objects using
+// either JSON pointers (RFC 6901) or a subset of JSONPath
+package fieldpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed sequence of segments leading to a field
+type Path struct {
+	text     string
+	segments []segment
+}
+
+// segment selects either a key of an object or elements of a list
+type segment struct {
+	key    string
+	index  int
+	kind   segmentKind
+	filter filter
+}
+
+type segmentKind int
+
+const (
+	keySegment segmentKind = iota
+	indexSegment
+	endSegment // the position after the last element, "-" in a pointer
+	allSegment
+	filterSegment
+)
+
+// filter selects the elements of a list whose field equals a value
+type filter struct {
+	key   string
+	value string
+}
+
+// Parse returns the Path for a JSON pointer, e.g.
+// /spec/template/spec/containers/0/image, or a JSONPath expression,
+// e.g. .spec.template.spec.containers[?(@.name=="app")].image. The
+// JSONPath may be enclosed in braces, as kubectl allows, and may
+// begin with $. Keys containing dots may be quoted in brackets, e.g.
+// .metadata.labels['app.kubernetes.io/name']. Other than [*] and
+// filters comparing a field to a literal, JSONPath operators aren't
+// supported.
+func Parse(text string) (*Path, error) {
+	var segments []segment
+	var err error
+	if strings.HasPrefix(text, "/") {
+		segments = parsePointer(text)
+	} else {
+		segments, err = parseJSONPath(text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", text, err)
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid path %q: no fields", text)
+	}
+	for _, s := range segments[:len(segments)-1] {
+		if s.kind == endSegment {
+			return nil, fmt.Errorf("invalid path %q: - may only end a path", text)
+		}
+	}
+	return &Path{text: text, segments: segments}, nil
+}
+
+// String returns the text from which the Path was parsed
+func (p *Path) String() string {
+	return p.text
+}
+
+func parsePointer(text string) []segment {
+	result := []segment{}
+	for _, token := range strings.Split(text[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		result = append(result, pointerSegment(token))
+	}
+	return result
+}
+
+// pointerSegment interprets a reference token, which may be either a
+// key or an index depending on the value to which it's applied
+func pointerSegment(token string) segment {
+	if token == "-" {
+		return segment{key: token, kind: endSegment}
+	}
+	if i, err := strconv.Atoi(token); err == nil && i >= 0 && (token == "0" || token[0] != '0') {
+		return segment{key: token, index: i, kind: indexSegment}
+	}
+	return segment{key: token}
+}
+
+func parseJSONPath(text string) ([]segment, error) {
+	s := strings.TrimSpace(text)
+	if strings.HasPrefix(s, "{") {
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("unbalanced braces")
+		}
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	s = strings.TrimPrefix(s, "$")
+	if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+	result := []segment{}
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			end := strings.IndexAny(s[1:], ".[") + 1
+			if end == 0 {
+				end = len(s)
+			}
+			key := s[1:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty field name")
+			}
+			if key == "*" || strings.ContainsAny(key, "]'\"()@?") {
+				return nil, fmt.Errorf("unsupported field name %q", key)
+			}
+			result = append(result, segment{key: key})
+			s = s[end:]
+		case '[':
+			end := closingBracket(s)
+			if end < 0 {
+				return nil, fmt.Errorf("unbalanced brackets")
+			}
+			seg, err := parseBracket(s[1:end])
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, seg)
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", s[0])
+		}
+	}
+	return result, nil
+}
+
+// closingBracket returns the index of the bracket closing the one at
+// the start of s, skipping any within quotes
+func closingBracket(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseBracket(s string) (segment, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "*":
+		return segment{kind: allSegment}, nil
+	case s == "-":
+		return segment{kind: endSegment}, nil
+	case strings.HasPrefix(s, "?"):
+		f, err := parseFilter(s[1:])
+		return segment{kind: filterSegment, filter: f}, err
+	}
+	if key, ok := unquote(s); ok {
+		return segment{key: key}, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		return segment{}, fmt.Errorf("invalid index %q", s)
+	}
+	return segment{index: i, kind: indexSegment}, nil
+}
+
+// parseFilter parses the expression (@.key=="value")
+func parseFilter(s string) (filter, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return filter{}, fmt.Errorf("invalid filter %q", s)
+	}
+	lhs, rhs, ok := strings.Cut(s[1:len(s)-1], "==")
+	lhs = strings.TrimSpace(lhs)
+	if !ok || !strings.HasPrefix(lhs, "@.") || len(lhs) == 2 {
+		return filter{}, fmt.Errorf("unsupported filter %q, only (@.field==\"value\") is", s)
+	}
+	rhs = strings.TrimSpace(rhs)
+	value, quoted := unquote(rhs)
+	if !quoted {
+		if _, err := strconv.ParseFloat(rhs, 64); err != nil && rhs != "true" && rhs != "false" {
+			return filter{}, fmt.Errorf("invalid value %s in filter", rhs)
+		}
+		value = rhs
+	}
+	return filter{key: lhs[2:], value: value}, nil
+}
+
+func unquote(s string) (string, bool) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+func (f filter) matches(element interface{}) bool {
+	m, ok := element.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	v, ok := m[f.key]
+	return ok && fmt.Sprint(v) == f.value
+}
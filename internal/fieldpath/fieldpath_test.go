@@ -0,0 +1,259 @@
+package fieldpath_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	. "github.com/manifestival/manifestival/internal/fieldpath"
+)
+
+const deployment = `
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: app
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+        args: [--verbose]
+      - name: sidecar
+        image: proxy:v1
+`
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"/spec/replicas",
+		"/metadata/labels/app.kubernetes.io~1name",
+		"/spec/template/spec/containers/-",
+		".spec.replicas",
+		"spec.replicas",
+		"{.spec.replicas}",
+		"$.spec.template.spec.containers[0].image",
+		".spec.template.spec.containers[*].image",
+		`.spec.template.spec.containers[?(@.name=="app")].image`,
+		".metadata.labels['app.kubernetes.io/name']",
+		".spec.template.spec.containers[-]",
+	}
+	for _, path := range valid {
+		if _, err := Parse(path); err != nil {
+			t.Errorf("Parse(%q) failed: %v", path, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"{}",
+		"{.spec",
+		".spec..replicas",
+		".spec.containers[0",
+		".spec.containers[-1]",
+		".spec.containers[x]",
+		".spec.containers[?(@.name)]",
+		".spec.containers[?(@.name==app)]",
+		".spec.containers[?(@.name>'app')]",
+		".spec.containers[-].image",
+		"/spec/containers/-/image",
+		".spec.*",
+	}
+	for _, path := range invalid {
+		if _, err := Parse(path); err == nil {
+			t.Errorf("Parse(%q) succeeded, wanted error", path)
+		} else if !strings.Contains(err.Error(), "invalid path") {
+			t.Errorf("Parse(%q) error %q doesn't mention the path", path, err)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		op        func(*Path, map[string]interface{}) error
+		field     string
+		want      interface{}
+		wantError string
+	}{{
+		name:  "set pointer",
+		path:  "/spec/replicas",
+		op:    set(int64(3)),
+		field: "/spec/replicas",
+		want:  int64(3),
+	}, {
+		name:  "set missing",
+		path:  ".spec.strategy.type",
+		op:    set("Recreate"),
+		field: "/spec/strategy",
+		want:  map[string]interface{}{"type": "Recreate"},
+	}, {
+		name:  "set quoted key",
+		path:  ".spec.template.metadata.labels['app.kubernetes.io/name']",
+		op:    set("web"),
+		field: "/spec/template/metadata/labels",
+		want:  map[string]interface{}{"app.kubernetes.io/name": "web"},
+	}, {
+		name:  "set filtered",
+		path:  `{.spec.template.spec.containers[?(@.name=="sidecar")].image}`,
+		op:    set("proxy:v2"),
+		field: "/spec/template/spec/containers/1/image",
+		want:  "proxy:v2",
+	}, {
+		name:  "set all",
+		path:  ".spec.template.spec.containers[*].imagePullPolicy",
+		op:    set("Always"),
+		field: "/spec/template/spec/containers/1/imagePullPolicy",
+		want:  "Always",
+	}, {
+		name:  "set end of list",
+		path:  "/spec/template/spec/containers/0/args/-",
+		op:    set("--debug"),
+		field: "/spec/template/spec/containers/0/args",
+		want:  []interface{}{"--verbose", "--debug"},
+	}, {
+		name:      "set beyond list",
+		path:      ".spec.template.spec.containers[2].image",
+		op:        set("x"),
+		wantError: ".spec.template.spec.containers has no element 2",
+	}, {
+		name:      "set beneath a scalar",
+		path:      ".spec.replicas.count",
+		op:        set(int64(1)),
+		wantError: ".spec.replicas is not an object",
+	}, {
+		name:      "index an object",
+		path:      ".spec.template[0]",
+		op:        set(int64(1)),
+		wantError: ".spec.template is not a list",
+	}, {
+		name:  "delete",
+		path:  ".spec.template.metadata.labels",
+		op:    remove,
+		field: "/spec/template/metadata",
+		want:  map[string]interface{}{},
+	}, {
+		name:  "delete element",
+		path:  `.spec.template.spec.containers[?(@.name=="sidecar")]`,
+		op:    remove,
+		field: "/spec/template/spec/containers/0/name",
+		want:  "app",
+	}, {
+		name:  "delete missing",
+		path:  ".spec.selector.matchLabels.app",
+		op:    remove,
+		field: "/spec/replicas",
+		want:  int64(1),
+	}, {
+		name:  "append",
+		path:  ".spec.template.spec.containers[0].args",
+		op:    appendTo("--debug", "--trace"),
+		field: "/spec/template/spec/containers/0/args",
+		want:  []interface{}{"--verbose", "--debug", "--trace"},
+	}, {
+		name:  "append missing",
+		path:  "/spec/template/spec/containers/1/args",
+		op:    appendTo("--debug"),
+		field: "/spec/template/spec/containers/1/args",
+		want:  []interface{}{"--debug"},
+	}, {
+		name:      "append to a scalar",
+		path:      "/spec/replicas",
+		op:        appendTo(int64(2)),
+		wantError: "/spec/replicas is not a list",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj := parse(t, deployment)
+			path, err := Parse(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = test.op(path, obj)
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("Expected error containing %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			field, err := Parse(test.field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := field.Get(obj); len(got) != 1 || !reflect.DeepEqual(got[0], test.want) {
+				t.Errorf("%s = %#v, want %#v", test.field, got, test.want)
+			}
+		})
+	}
+}
+
+func TestJSONValue(t *testing.T) {
+	got, err := JSONValue(map[string]interface{}{"ports": []int{80, 443}, "ratio": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"ports": []interface{}{int64(80), int64(443)}, "ratio": 0.5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONValue() = %#v, want %#v", got, want)
+	}
+	if _, err := JSONValue(func() {}); err == nil {
+		t.Error("Expected an error for a value that can't be represented as JSON")
+	}
+}
+
+func TestGet(t *testing.T) {
+	obj := parse(t, deployment)
+	tests := map[string][]interface{}{
+		".spec.replicas":                                        {int64(1)},
+		".spec.template.spec.containers[*].name":                {"app", "sidecar"},
+		"/spec/template/spec/containers/1/image":                {"proxy:v1"},
+		".spec.template.spec.containers[3].name":                {},
+		".spec.template.spec.volumes[*].name":                   {},
+		"/spec/template/spec/containers/-":                      {},
+		`.spec.template.spec.containers[?(@.name=="app")].args`: {[]interface{}{"--verbose"}},
+	}
+	for path, want := range tests {
+		p, err := Parse(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Get(obj); !reflect.DeepEqual(got, want) {
+			t.Errorf("Get(%s) = %#v, want %#v", path, got, want)
+		}
+	}
+}
+
+func set(value interface{}) func(*Path, map[string]interface{}) error {
+	return func(p *Path, obj map[string]interface{}) error {
+		return p.Set(obj, value)
+	}
+}
+
+func remove(p *Path, obj map[string]interface{}) error {
+	return p.Delete(obj)
+}
+
+func appendTo(values ...interface{}) func(*Path, map[string]interface{}) error {
+	return func(p *Path, obj map[string]interface{}) error {
+		return p.Append(obj, values...)
+	}
+}
+
+func parse(t *testing.T, doc string) map[string]interface{} {
+	t.Helper()
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+		t.Fatal(err)
+	}
+	// Convert float64s to int64s, as in unstructured objects
+	v, err := JSONValue(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.(map[string]interface{})
+}
@@ -0,0 +1,216 @@
+package fieldpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// operation returns the new value of a field matched by the last
+// segment of a path, or false if it should be removed
+type operation func(current interface{}, found bool) (interface{}, bool, error)
+
+// Set sets the fields matched by the path to copies of value, which
+// must be a JSON value as returned by JSONValue, creating any
+// missing objects leading to them
+func (p *Path) Set(obj map[string]interface{}, value interface{}) error {
+	return p.update(obj, true, func(interface{}, bool) (interface{}, bool, error) {
+		return runtime.DeepCopyJSONValue(value), true, nil
+	})
+}
+
+// Get returns the values of the fields matched by the path
+func (p *Path) Get(obj map[string]interface{}) []interface{} {
+	result := []interface{}{}
+	p.update(obj, false, func(current interface{}, found bool) (interface{}, bool, error) {
+		if found {
+			result = append(result, current)
+		}
+		return current, found, nil
+	})
+	return result
+}
+
+// Delete removes the fields or list elements matched by the path,
+// ignoring any that don't exist
+func (p *Path) Delete(obj map[string]interface{}) error {
+	return p.update(obj, false, func(interface{}, bool) (interface{}, bool, error) {
+		return nil, false, nil
+	})
+}
+
+// Append appends copies of values to the lists matched by the path,
+// creating them if they don't exist
+func (p *Path) Append(obj map[string]interface{}, values ...interface{}) error {
+	return p.update(obj, true, func(current interface{}, found bool) (interface{}, bool, error) {
+		var list []interface{}
+		if found && current != nil {
+			var ok bool
+			if list, ok = current.([]interface{}); !ok {
+				return nil, false, fmt.Errorf("%s is not a list", p)
+			}
+		}
+		for _, v := range values {
+			list = append(list, runtime.DeepCopyJSONValue(v))
+		}
+		return list, true, nil
+	})
+}
+
+func (p *Path) update(obj map[string]interface{}, create bool, op operation) error {
+	_, err := p.walk(obj, 0, create, op)
+	return err
+}
+
+// walk applies op to the fields beneath node matched by the segments
+// from i onwards, returning the node's new value
+func (p *Path) walk(node interface{}, i int, create bool, op operation) (interface{}, error) {
+	s := p.segments[i]
+	last := i == len(p.segments)-1
+	if _, ok := node.(map[string]interface{}); ok && s.key != "" {
+		// Pointer tokens that look like indexes may also be keys
+		s.kind = keySegment
+	}
+	if node == nil {
+		if !create {
+			return nil, nil
+		}
+		if s.kind == keySegment {
+			node = map[string]interface{}{}
+		} else {
+			node = []interface{}{}
+		}
+	}
+	switch s.kind {
+	case keySegment:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, p.errorAt(i, "is not an object")
+		}
+		child, found := m[s.key]
+		if last {
+			v, keep, err := op(child, found)
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				m[s.key] = v
+			} else {
+				delete(m, s.key)
+			}
+			return m, nil
+		}
+		if !found && !create {
+			return m, nil
+		}
+		v, err := p.walk(child, i+1, create, op)
+		if err != nil {
+			return nil, err
+		}
+		m[s.key] = v
+		return m, nil
+	}
+
+	list, ok := node.([]interface{})
+	if !ok {
+		return nil, p.errorAt(i, "is not a list")
+	}
+	switch s.kind {
+	case indexSegment:
+		if s.index >= len(list) {
+			if !create {
+				return list, nil
+			}
+			return nil, p.errorAt(i, fmt.Sprintf("has no element %d", s.index))
+		}
+		return p.elements(list, i, create, op, func(j int, _ interface{}) bool {
+			return j == s.index
+		})
+	case endSegment:
+		v, keep, err := op(nil, false)
+		if err != nil || !keep {
+			return list, err
+		}
+		return append(list, v), nil
+	case allSegment:
+		return p.elements(list, i, create, op, func(int, interface{}) bool {
+			return true
+		})
+	default:
+		return p.elements(list, i, create, op, func(_ int, e interface{}) bool {
+			return s.filter.matches(e)
+		})
+	}
+}
+
+// elements applies op to the elements of list selected by the i'th
+// segment of the path, or to the fields beneath them
+func (p *Path) elements(list []interface{}, i int, create bool, op operation, selected func(int, interface{}) bool) (interface{}, error) {
+	last := i == len(p.segments)-1
+	result := make([]interface{}, 0, len(list))
+	for j, e := range list {
+		if !selected(j, e) {
+			result = append(result, e)
+			continue
+		}
+		var err error
+		keep := true
+		if last {
+			e, keep, err = op(e, true)
+		} else {
+			e, err = p.walk(e, i+1, create, op)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// errorAt describes a problem with the value matched by the segments
+// preceding the i'th
+func (p *Path) errorAt(i int, problem string) error {
+	b := &strings.Builder{}
+	for _, s := range p.segments[:i] {
+		switch s.kind {
+		case keySegment:
+			if strings.ContainsAny(s.key, ".[]") {
+				fmt.Fprintf(b, "[%q]", s.key)
+			} else {
+				b.WriteString("." + s.key)
+			}
+		case indexSegment:
+			b.WriteString("[" + strconv.Itoa(s.index) + "]")
+		case allSegment:
+			b.WriteString("[*]")
+		case filterSegment:
+			fmt.Fprintf(b, "[?(@.%s==%q)]", s.filter.key, s.filter.value)
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("the resource")
+	}
+	return fmt.Errorf("%s: %s %s", p, b, problem)
+}
+
+// JSONValue converts v to the types used by unstructured objects,
+// e.g. a []string to a []interface{} and an int to an int64, by
+// round-tripping it through JSON
+func JSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := utiljson.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/manifestival/manifestival/internal/git"
+)
+
+const (
+	v100 = "4d48966b3c491d3d983ffdb9583ac4e6c80c4b84"
+	v110 = "f85ed763f290e9f611b42974aeada5bc42f30e10"
+	head = "d8ff16f6e715a54946eb730368b422929b670b73"
+)
+
+func TestResolve(t *testing.T) {
+	repo, err := Open("testdata/repo.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rev       string
+		want      string
+		wantError bool
+	}{
+		{rev: "", want: head},
+		{rev: "HEAD", want: head},
+		{rev: "main", want: head},
+		{rev: "refs/heads/main", want: head},
+		{rev: "v1.0.0", want: v100}, // annotated, packed
+		{rev: "tags/v1.1.0", want: v110},
+		{rev: "f85ed76", want: v110},
+		{rev: strings.ToUpper(v100), want: v100},
+		{rev: "config", wantError: true},
+		{rev: "v9.9.9", wantError: true},
+		{rev: "0000000", wantError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.rev, func(t *testing.T) {
+			h, err := repo.Resolve(test.rev)
+			if test.wantError {
+				if err == nil {
+					t.Fatalf("Resolve(%q) = %s, wanted error", test.rev, h)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.String() != test.want {
+				t.Errorf("Resolve(%q) = %s, want %s", test.rev, h, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveHexRefs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "repo.git")
+	err := filepath.Walk("testdata/repo.git", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, strings.TrimPrefix(path, "testdata/repo.git"))
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, branch := range []string{"Add", "BEEF", "F85ED76"} {
+		if err := os.WriteFile(filepath.Join(dir, "refs", "heads", branch), []byte(v100+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rev, want := range map[string]string{"Add": v100, "BEEF": v100, "F85ED76": v100, "f85ed76": v110} {
+		if h, err := repo.Resolve(rev); err != nil || h.String() != want {
+			t.Errorf("Resolve(%q) = %s, %v, want %s", rev, h, err, want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	repo, err := Open("testdata/repo.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		rev       string
+		subpath   string
+		recursive bool
+		want      []string
+		contains  string
+		wantError bool
+	}{{
+		name:      "root",
+		rev:       head,
+		recursive: true,
+		want:      []string{"README.md", "config/deployment.yaml", "config/extra/configmap.yaml", "config/service.yaml"},
+	}, {
+		name:     "directory",
+		rev:      head,
+		subpath:  "config",
+		want:     []string{"config/deployment.yaml", "config/service.yaml"},
+		contains: "image: example.com/app:v1.1.0",
+	}, {
+		name:     "loose objects",
+		rev:      head,
+		subpath:  "/config/service.yaml",
+		want:     []string{"config/service.yaml"},
+		contains: "port: 8080",
+	}, {
+		name:     "packed objects",
+		rev:      v100,
+		subpath:  "config/deployment.yaml",
+		want:     []string{"config/deployment.yaml"},
+		contains: "image: example.com/app:v1.0.0",
+	}, {
+		name:     "delta",
+		rev:      v110,
+		subpath:  "config/deployment.yaml",
+		want:     []string{"config/deployment.yaml"},
+		contains: "image: example.com/app:v1.1.0",
+	}, {
+		name:      "missing at revision",
+		rev:       v100,
+		subpath:   "config/extra",
+		wantError: true,
+	}, {
+		name:      "beneath a file",
+		rev:       head,
+		subpath:   "README.md/config",
+		wantError: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := repo.Resolve(test.rev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, err := repo.Files(h, test.subpath, test.recursive)
+			if test.wantError {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			paths := []string{}
+			for _, f := range files {
+				paths = append(paths, f.Path)
+			}
+			if !reflect.DeepEqual(paths, test.want) {
+				t.Errorf("Files() = %v, want %v", paths, test.want)
+			}
+			if test.contains != "" && !strings.Contains(string(files[0].Data), test.contains) {
+				t.Errorf("Expected %s to contain %q:\n%s", files[0].Path, test.contains, files[0].Data)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	gitdir, err := filepath.Abs("testdata/repo.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+gitdir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Open(worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, err := repo.Resolve("HEAD"); err != nil || h.String() != head {
+		t.Errorf("Resolve(HEAD) = %s, %v", h, err)
+	}
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Error("Expected an error opening an empty directory")
+	}
+}
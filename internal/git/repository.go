@@ -0,0 +1,172 @@
+// Package git reads files from local git repositories, either bare or
+// with a working tree, at any revision, using go-git.
+package git
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Hash identifies an object
+type Hash = plumbing.Hash
+
+// File is a regular file within a commit
+type File struct {
+	Path string
+	Data []byte
+}
+
+// Repository is a local git repository
+type Repository struct {
+	repo *gogit.Repository
+}
+
+// Open returns the repository at path, which may be a bare
+// repository, a working tree, or a working tree's .git directory
+func Open(path string) (*Repository, error) {
+	repo, err := gogit.PlainOpenWithOptions(path, &gogit.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Repository{repo}, nil
+}
+
+// Resolve returns the commit identified by rev, which may be HEAD, a
+// full or abbreviated object name, a branch or tag name, or a full
+// ref name. Annotated tags are peeled to the commit they tag.
+func (r *Repository) Resolve(rev string) (Hash, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	target := rev
+	if name, ok := r.ref(rev); ok {
+		target = name.String()
+	} else if isHex(rev) {
+		// object names, unlike refs, are case-insensitive
+		target = strings.ToLower(rev)
+	}
+	h, err := r.repo.ResolveRevision(plumbing.Revision(target))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to resolve revision %q: %w", rev, err)
+	}
+	return *h, nil
+}
+
+// ref returns the full name of the ref that rev abbreviates, e.g.
+// refs/tags/v1.0.0 for v1.0.0, or false if there is none
+func (r *Repository) ref(rev string) (plumbing.ReferenceName, bool) {
+	for _, rule := range plumbing.RefRevParseRules {
+		name := plumbing.ReferenceName(fmt.Sprintf(rule, rev))
+		if _, err := r.repo.Reference(name, true); err == nil {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Files returns the regular files at subpath within the tree of a
+// commit, ordered by path. If subpath is a directory, only its
+// immediate children are returned unless recursive is true. Symbolic
+// links and submodules are ignored.
+func (r *Repository) Files(commit Hash, subpath string, recursive bool) ([]File, error) {
+	c, err := r.repo.CommitObject(commit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", commit, err)
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	subpath = strings.Trim(path.Clean("/"+subpath), "/")
+	if subpath != "" {
+		entry, err := tree.FindEntry(subpath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: no such file or directory at %s", subpath, commit)
+		}
+		if entry.Mode != filemode.Dir {
+			data, err := r.blob(entry)
+			if err != nil {
+				return nil, err
+			}
+			return []File{{subpath, data}}, nil
+		}
+		if tree, err = r.repo.TreeObject(entry.Hash); err != nil {
+			return nil, err
+		}
+	}
+	files, err := r.walk(tree, subpath, recursive)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// walk returns the files within a tree
+func (r *Repository) walk(tree *object.Tree, dir string, recursive bool) ([]File, error) {
+	result := []File{}
+	for i := range tree.Entries {
+		e := &tree.Entries[i]
+		name := path.Join(dir, e.Name)
+		switch e.Mode {
+		case filemode.Dir:
+			if !recursive {
+				continue
+			}
+			subtree, err := r.repo.TreeObject(e.Hash)
+			if err != nil {
+				return nil, err
+			}
+			files, err := r.walk(subtree, name, recursive)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, files...)
+		case filemode.Regular, filemode.Executable, filemode.Deprecated:
+			data, err := r.blob(e)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, File{name, data})
+		}
+	}
+	return result, nil
+}
+
+// blob returns the content of a regular file
+func (r *Repository) blob(e *object.TreeEntry) ([]byte, error) {
+	switch e.Mode {
+	case filemode.Regular, filemode.Executable, filemode.Deprecated:
+	default:
+		return nil, fmt.Errorf("%s is not a regular file", e.Name)
+	}
+	blob, err := r.repo.BlobObject(e.Hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,213 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Chart is a Helm chart loaded from a directory or archive
+type Chart struct {
+	Metadata     Metadata
+	Values       map[string]interface{}
+	Templates    []File
+	CRDs         []File
+	Files        Files
+	Dependencies []*Chart
+}
+
+// Metadata is the content of Chart.yaml, exposed to templates as
+// .Chart
+type Metadata struct {
+	APIVersion   string            `json:"apiVersion,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Version      string            `json:"version,omitempty"`
+	AppVersion   string            `json:"appVersion,omitempty"`
+	KubeVersion  string            `json:"kubeVersion,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Type         string            `json:"type,omitempty"`
+	Home         string            `json:"home,omitempty"`
+	Keywords     []string          `json:"keywords,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Dependencies []Dependency      `json:"dependencies,omitempty"`
+}
+
+// Dependency declares a subchart, which must be present in the
+// chart's charts/ directory, since nothing is ever downloaded
+type Dependency struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version,omitempty"`
+	Repository string   `json:"repository,omitempty"`
+	Alias      string   `json:"alias,omitempty"`
+	Condition  string   `json:"condition,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// File is a named file within a chart
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Load reads a chart from either a directory or a .tgz archive
+func Load(pathname string) (*Chart, error) {
+	info, err := os.Stat(pathname)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return loadDir(pathname)
+	}
+	f, err := os.Open(pathname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return loadArchive(f)
+}
+
+// loadDir reads every file beneath dir
+func loadDir(dir string) (*Chart, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return build(files)
+}
+
+// loadArchive reads a gzipped tarball whose entries share a single
+// top-level directory, as produced by `helm package`
+func loadArchive(r io.Reader) (*Chart, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) < 2 || strings.HasPrefix(parts[1], "../") {
+			continue
+		}
+		if files[parts[1]], err = io.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+	return build(files)
+}
+
+// build assembles a Chart from its files, keyed by relative path,
+// except for those matched by its .helmignore file
+func build(files map[string][]byte) (*Chart, error) {
+	data, ok := files["Chart.yaml"]
+	if !ok {
+		return nil, fmt.Errorf("Chart.yaml not found")
+	}
+	c := &Chart{Values: map[string]interface{}{}, Files: Files{}}
+	if err := yaml.Unmarshal(data, &c.Metadata); err != nil {
+		return nil, fmt.Errorf("invalid Chart.yaml: %w", err)
+	}
+	if c.Metadata.Name == "" {
+		return nil, fmt.Errorf("Chart.yaml has no name")
+	}
+	rules := parseIgnore(files[".helmignore"])
+	subcharts := map[string]map[string][]byte{}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if name == "Chart.yaml" || !ignored(rules, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data := files[name]
+		switch {
+		case name == "Chart.yaml":
+		case name == "values.yaml":
+			if err := yaml.Unmarshal(data, &c.Values); err != nil {
+				return nil, fmt.Errorf("chart %s: invalid values.yaml: %w", c.Metadata.Name, err)
+			}
+			if c.Values == nil {
+				c.Values = map[string]interface{}{}
+			}
+		case name == "requirements.yaml":
+			// apiVersion v1 charts declare dependencies separately
+			var req struct {
+				Dependencies []Dependency `json:"dependencies"`
+			}
+			if err := yaml.Unmarshal(data, &req); err != nil {
+				return nil, fmt.Errorf("chart %s: invalid requirements.yaml: %w", c.Metadata.Name, err)
+			}
+			c.Metadata.Dependencies = append(c.Metadata.Dependencies, req.Dependencies...)
+		case strings.HasPrefix(name, "templates/"):
+			c.Templates = append(c.Templates, File{name, data})
+		case strings.HasPrefix(name, "crds/"):
+			c.CRDs = append(c.CRDs, File{name, data})
+		case strings.HasPrefix(name, "charts/"):
+			rest := strings.TrimPrefix(name, "charts/")
+			if parts := strings.SplitN(rest, "/", 2); len(parts) == 2 {
+				if subcharts[parts[0]] == nil {
+					subcharts[parts[0]] = map[string][]byte{}
+				}
+				subcharts[parts[0]][parts[1]] = data
+			} else if strings.HasSuffix(rest, ".tgz") {
+				sub, err := loadArchive(bytes.NewReader(data))
+				if err != nil {
+					return nil, fmt.Errorf("chart %s: %s: %w", c.Metadata.Name, name, err)
+				}
+				c.Dependencies = append(c.Dependencies, sub)
+			}
+		default:
+			c.Files[name] = data
+		}
+	}
+	dirs := make([]string, 0, len(subcharts))
+	for dir := range subcharts {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		sub, err := build(subcharts[dir])
+		if err != nil {
+			return nil, fmt.Errorf("chart %s: charts/%s: %w", c.Metadata.Name, dir, err)
+		}
+		c.Dependencies = append(c.Dependencies, sub)
+	}
+	return c, nil
+}
@@ -0,0 +1,82 @@
+package helm
+
+import (
+	"encoding/base64"
+	"path"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Files are the non-template files of a chart, exposed to templates
+// as .Files
+type Files map[string][]byte
+
+// Get returns the content of a file, or "" if it doesn't exist
+func (f Files) Get(name string) string {
+	return string(f.GetBytes(name))
+}
+
+// GetBytes returns the content of a file, or nil if it doesn't exist
+func (f Files) GetBytes(name string) []byte {
+	return f[name]
+}
+
+// Glob returns the files whose names match pattern
+func (f Files) Glob(pattern string) Files {
+	result := Files{}
+	for name, data := range f {
+		if ok, _ := path.Match(pattern, name); ok {
+			result[name] = data
+		}
+	}
+	return result
+}
+
+// Lines returns the lines of a file
+func (f Files) Lines(name string) []string {
+	if f[name] == nil {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(f.Get(name), "\n"), "\n")
+}
+
+// AsConfig renders the files as ConfigMap data, keyed by base name
+func (f Files) AsConfig() string {
+	m := map[string]string{}
+	for _, name := range f.names() {
+		m[path.Base(name)] = f.Get(name)
+	}
+	return toYAML(m)
+}
+
+// AsSecrets renders the files as base64-encoded Secret data, keyed by
+// base name
+func (f Files) AsSecrets() string {
+	m := map[string]string{}
+	for _, name := range f.names() {
+		m[path.Base(name)] = base64.StdEncoding.EncodeToString(f[name])
+	}
+	return toYAML(m)
+}
+
+func (f Files) names() []string {
+	result := make([]string, 0, len(f))
+	for name := range f {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func toYAML(v interface{}) string {
+	if m, ok := v.(map[string]string); ok && len(m) == 0 {
+		return ""
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
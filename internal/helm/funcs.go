@@ -0,0 +1,325 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/manifestival/manifestival/internal/sources"
+)
+
+// funcMap extends the functions available to Template sources with
+// the subset of the Sprig library commonly used by charts. Functions
+// requiring the template itself, e.g. include and tpl, are added by
+// the renderer.
+func funcMap() template.FuncMap {
+	result := sources.TemplateFuncs()
+	extras := template.FuncMap{
+		"fail":            func(msg string) (string, error) { return "", errors.New(msg) },
+		"toString":        toString,
+		"toStrings":       toStrings,
+		"int":             func(v interface{}) int { return int(toInt64(v)) },
+		"int64":           toInt64,
+		"float64":         toFloat64,
+		"add":             func(a, b interface{}) int64 { return toInt64(a) + toInt64(b) },
+		"add1":            func(a interface{}) int64 { return toInt64(a) + 1 },
+		"sub":             func(a, b interface{}) int64 { return toInt64(a) - toInt64(b) },
+		"mul":             func(a, b interface{}) int64 { return toInt64(a) * toInt64(b) },
+		"div":             div,
+		"mod":             mod,
+		"max":             maxInt,
+		"min":             minInt,
+		"trunc":           trunc,
+		"trimSuffix":      func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"trimPrefix":      func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimAll":         func(cutset, s string) string { return strings.Trim(s, cutset) },
+		"hasPrefix":       func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":       func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"title":           title,
+		"repeat":          func(n int, s string) string { return strings.Repeat(s, n) },
+		"nospace":         func(s string) string { return strings.Join(strings.Fields(s), "") },
+		"cat":             cat,
+		"join":            join,
+		"splitList":       func(sep, s string) []string { return strings.Split(s, sep) },
+		"b64enc":          func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":          b64dec,
+		"sha256sum":       sha256sum,
+		"ternary":         ternary,
+		"kindIs":          func(kind string, v interface{}) bool { return kindOf(v) == kind },
+		"kindOf":          kindOf,
+		"typeOf":          func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"regexMatch":      regexMatch,
+		"regexReplaceAll": regexReplaceAll,
+		"semverCompare":   semverCompare,
+		"tuple":           sources.TemplateFuncs()["list"],
+		"first":           first,
+		"last":            last,
+		"append":          func(l []interface{}, v interface{}) []interface{} { return append(append([]interface{}{}, l...), v) },
+		"has":             has,
+		"until":           until,
+		"hasKey":          func(m map[string]interface{}, k string) bool { _, ok := m[k]; return ok },
+		"get":             func(m map[string]interface{}, k string) interface{} { return m[k] },
+		"set":             func(m map[string]interface{}, k string, v interface{}) map[string]interface{} { m[k] = v; return m },
+		"unset":           func(m map[string]interface{}, k string) map[string]interface{} { delete(m, k); return m },
+		"keys":            keys,
+		"merge":           merge,
+		"deepCopy":        copyValue,
+	}
+	for k, v := range extras {
+		result[k] = v
+	}
+	return result
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case fmt.Stringer:
+		return s.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func toStrings(v interface{}) []string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []string{toString(v)}
+	}
+	result := make([]string, rv.Len())
+	for i := range result {
+		result[i] = toString(rv.Index(i).Interface())
+	}
+	return result
+}
+
+func toInt64(v interface{}) int64 {
+	switch x := v.(type) {
+	case string:
+		i, _ := strconv.ParseInt(x, 10, 64)
+		return i
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float())
+	}
+	return 0
+}
+
+func toFloat64(v interface{}) float64 {
+	switch x := v.(type) {
+	case string:
+		f, _ := strconv.ParseFloat(x, 64)
+		return f
+	case float32:
+		return float64(x)
+	case float64:
+		return x
+	}
+	return float64(toInt64(v))
+}
+
+func div(a, b interface{}) (int64, error) {
+	if toInt64(b) == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return toInt64(a) / toInt64(b), nil
+}
+
+func mod(a, b interface{}) (int64, error) {
+	if toInt64(b) == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return toInt64(a) % toInt64(b), nil
+}
+
+func maxInt(a interface{}, rest ...interface{}) int64 {
+	result := toInt64(a)
+	for _, v := range rest {
+		if i := toInt64(v); i > result {
+			result = i
+		}
+	}
+	return result
+}
+
+func minInt(a interface{}, rest ...interface{}) int64 {
+	result := toInt64(a)
+	for _, v := range rest {
+		if i := toInt64(v); i < result {
+			result = i
+		}
+	}
+	return result
+}
+
+// trunc limits s to n characters; a negative n keeps the last -n
+func trunc(n int, s string) string {
+	switch {
+	case n >= 0 && len(s) > n:
+		return s[:n]
+	case n < 0 && len(s) > -n:
+		return s[len(s)+n:]
+	}
+	return s
+}
+
+func title(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func cat(v ...interface{}) string {
+	result := []string{}
+	for _, x := range v {
+		if x != nil {
+			result = append(result, toString(x))
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+func join(sep string, v interface{}) string {
+	return strings.Join(toStrings(v), sep)
+}
+
+func b64dec(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	return string(data), err
+}
+
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func ternary(t, f interface{}, cond bool) interface{} {
+	if cond {
+		return t
+	}
+	return f
+}
+
+func kindOf(v interface{}) string {
+	if v == nil {
+		return "invalid"
+	}
+	return reflect.ValueOf(v).Kind().String()
+}
+
+func regexMatch(pattern, s string) (bool, error) {
+	return regexp.MatchString(pattern, s)
+}
+
+func regexReplaceAll(pattern, s, repl string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(s, repl), nil
+}
+
+func first(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Len() > 0 {
+		return rv.Index(0).Interface()
+	}
+	return nil
+}
+
+func last(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Len() > 0 {
+		return rv.Index(rv.Len() - 1).Interface()
+	}
+	return nil
+}
+
+func has(needle, haystack interface{}) bool {
+	rv := reflect.ValueOf(haystack)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if reflect.DeepEqual(rv.Index(i).Interface(), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func until(n int) []int {
+	result := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		result = append(result, i)
+	}
+	return result
+}
+
+func keys(maps ...map[string]interface{}) []string {
+	result := []string{}
+	for _, m := range maps {
+		for k := range m {
+			result = append(result, k)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// merge deep merges the srcs into dst, without overwriting its values
+func merge(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	for _, src := range srcs {
+		for k, v := range src {
+			dm, dok := dst[k].(map[string]interface{})
+			sm, sok := v.(map[string]interface{})
+			switch _, exists := dst[k]; {
+			case !exists:
+				dst[k] = v
+			case dok && sok:
+				merge(dm, sm)
+			}
+		}
+	}
+	return dst
+}
+
+// semverCompare tests a version against a constraint such as
+// ">=1.19-0" or "^1.20", as Helm does
+func semverCompare(constraint, version string) (bool, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
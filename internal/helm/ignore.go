@@ -0,0 +1,74 @@
+package helm
+
+import (
+	"path"
+	"strings"
+)
+
+// defaultIgnore is always ignored, as it is by Helm
+const defaultIgnore = "templates/.?*"
+
+// ignoreRule is a pattern of a .helmignore file
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	// base rules, lacking a slash, match the last element of a path
+	base bool
+}
+
+// parseIgnore returns the rules of a .helmignore file, which are
+// like those of .gitignore, though without support for "**"
+func parseIgnore(data []byte) []ignoreRule {
+	result := []ignoreRule{}
+	for _, line := range strings.Split(defaultIgnore+"\n"+string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		rule.base = !strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if _, err := path.Match(rule.pattern, ""); err != nil || rule.pattern == "" {
+			continue
+		}
+		result = append(result, rule)
+	}
+	return result
+}
+
+// ignored returns true if the file at name, relative to the chart's
+// directory, or any directory containing it, is matched by the rules.
+// Later rules take precedence over earlier ones.
+func ignored(rules []ignoreRule, name string) bool {
+	parts := strings.Split(name, "/")
+	for i := 1; i <= len(parts); i++ {
+		if matches(rules, strings.Join(parts[:i], "/"), i < len(parts)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(rules []ignoreRule, name string, dir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+		target := name
+		if rule.base {
+			target = path.Base(name)
+		}
+		if ok, _ := path.Match(rule.pattern, target); ok {
+			result = !rule.negate
+		}
+	}
+	return result
+}
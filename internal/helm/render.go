@@ -0,0 +1,268 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/manifestival/manifestival/internal/sources"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// DefaultKubeVersion is reported by .Capabilities.KubeVersion when
+// no version is specified
+const DefaultKubeVersion = "v1.27.0"
+
+// Options describe the release and the cluster for which a chart is
+// rendered, since there's no cluster to ask
+type Options struct {
+	ReleaseName string
+	Namespace   string
+	KubeVersion string
+	APIVersions []string
+}
+
+// Capabilities is exposed to templates as .Capabilities
+type Capabilities struct {
+	KubeVersion KubeVersion
+	APIVersions VersionSet
+}
+
+// KubeVersion is exposed to templates as .Capabilities.KubeVersion
+type KubeVersion struct {
+	Version string
+	Major   string
+	Minor   string
+}
+
+func (k KubeVersion) String() string {
+	return k.Version
+}
+
+// GitVersion is a deprecated alias of Version, retained by Helm
+func (k KubeVersion) GitVersion() string {
+	return k.Version
+}
+
+// VersionSet is a set of "group/version" or "group/version/Kind"
+// strings
+type VersionSet []string
+
+// Has returns true if the set contains apiVersion
+func (v VersionSet) Has(apiVersion string) bool {
+	for _, x := range v {
+		if x == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// Render evaluates the templates of c and its enabled dependencies
+// with vals overriding the chart's defaults, returning the resulting
+// resources, those in crds/ first, sorted in the order Helm would
+// install them.
+func Render(c *Chart, vals map[string]interface{}, opts Options) ([]unstructured.Unstructured, error) {
+	caps, err := capabilities(opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.Metadata.KubeVersion != "" {
+		ok, err := semverCompare(c.Metadata.KubeVersion, caps.KubeVersion.Version)
+		if err != nil {
+			return nil, fmt.Errorf("chart %s: invalid kubeVersion %s: %w", c.Metadata.Name, c.Metadata.KubeVersion, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("chart %s requires kubeVersion %s, which is incompatible with %s", c.Metadata.Name, c.Metadata.KubeVersion, caps.KubeVersion.Version)
+		}
+	}
+	scopes, err := resolve(c, copyValues(vals), c.Metadata.Name)
+	if err != nil {
+		return nil, err
+	}
+	release := map[string]interface{}{
+		"Name":      opts.ReleaseName,
+		"Namespace": opts.Namespace,
+		"Service":   "Helm",
+		"Revision":  1,
+		"IsInstall": true,
+		"IsUpgrade": false,
+	}
+	if release["Name"] == "" {
+		release["Name"] = "release-name"
+	}
+	if release["Namespace"] == "" {
+		release["Namespace"] = "default"
+	}
+
+	t := template.New(c.Metadata.Name).Option("missingkey=zero")
+	funcs := funcMap()
+	funcs["include"] = func(name string, data interface{}) (string, error) {
+		var buf bytes.Buffer
+		err := t.ExecuteTemplate(&buf, name, data)
+		return buf.String(), err
+	}
+	funcs["tpl"] = func(text string, data interface{}) (string, error) {
+		tt := template.New("tpl").Option("missingkey=zero").Funcs(funcs)
+		for _, x := range t.Templates() {
+			if x.Tree != nil && x.Name() != tt.Name() {
+				if _, err := tt.AddParseTree(x.Name(), x.Tree); err != nil {
+					return "", err
+				}
+			}
+		}
+		if _, err := tt.Parse(text); err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		err := sources.EmptyMissing(tt).Execute(&buf, data)
+		return buf.String(), err
+	}
+	funcs["lookup"] = func(...string) map[string]interface{} {
+		// there's no cluster to query
+		return map[string]interface{}{}
+	}
+	t.Funcs(funcs)
+
+	type target struct {
+		name string
+		data map[string]interface{}
+	}
+	targets := []target{}
+	crds := [][]byte{}
+	for _, s := range scopes {
+		for _, f := range s.chart.CRDs {
+			crds = append(crds, f.Data)
+		}
+		for _, f := range s.chart.Templates {
+			name := s.prefix + "/" + f.Name
+			if _, err := t.New(name).Parse(string(f.Data)); err != nil {
+				return nil, err
+			}
+			base := path.Base(f.Name)
+			if strings.HasPrefix(base, "_") || base == "NOTES.txt" {
+				continue
+			}
+			targets = append(targets, target{name, map[string]interface{}{
+				"Values":       s.values,
+				"Release":      release,
+				"Chart":        s.chart.Metadata,
+				"Capabilities": caps,
+				"Files":        s.chart.Files,
+				"Template": map[string]interface{}{
+					"Name":     name,
+					"BasePath": s.prefix + "/templates",
+				},
+			}})
+		}
+	}
+	sort.SliceStable(targets, func(i, j int) bool { return targets[i].name < targets[j].name })
+
+	// missing values are printed as empty, as they are by Helm
+	sources.EmptyMissing(t)
+
+	result, err := sources.DecodeAll(crds)
+	if err != nil {
+		return nil, err
+	}
+	n := len(result)
+	for _, x := range targets {
+		var buf bytes.Buffer
+		if err := t.ExecuteTemplate(&buf, x.name, x.data); err != nil {
+			return nil, err
+		}
+		resources, err := sources.Decode(&buf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", x.name, err)
+		}
+		result = append(result, resources...)
+	}
+	sort.SliceStable(result[n:], func(i, j int) bool {
+		return installOrder(result[n+i].GetKind()) < installOrder(result[n+j].GetKind())
+	})
+	return result, nil
+}
+
+// capabilities stubs what would otherwise be discovered from a cluster
+func capabilities(opts Options) (*Capabilities, error) {
+	version := opts.KubeVersion
+	if version == "" {
+		version = DefaultKubeVersion
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KubeVersion %s: %w", version, err)
+	}
+	result := &Capabilities{
+		KubeVersion: KubeVersion{
+			Version: version,
+			Major:   fmt.Sprint(v.Major()),
+			Minor:   fmt.Sprint(v.Minor()),
+		},
+	}
+	for gvk := range scheme.Scheme.AllKnownTypes() {
+		gv := gvk.GroupVersion().String()
+		result.APIVersions = append(result.APIVersions, gv, gv+"/"+gvk.Kind)
+	}
+	sort.Strings(result.APIVersions)
+	result.APIVersions = append(result.APIVersions, opts.APIVersions...)
+	return result, nil
+}
+
+// installOrder ranks resources by kind as Helm does when installing,
+// with unknown kinds last
+func installOrder(kind string) int {
+	for i, k := range kinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kinds)
+}
+
+var kinds = []string{
+	"PriorityClass",
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+}
@@ -0,0 +1,227 @@
+package helm_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival/internal/helm"
+)
+
+func TestRender(t *testing.T) {
+	chart, err := Load("testdata/mychart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]interface{}{
+		"replicaCount": 3,
+		"image":        map[string]interface{}{"tag": "1.25"},
+	}
+	resources, err := Render(chart, values, Options{ReleaseName: "foo", Namespace: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []string{}
+	for _, u := range resources {
+		kinds = append(kinds, u.GetKind()+"/"+u.GetName())
+	}
+	want := []string{
+		"CustomResourceDefinition/widgets.example.com",
+		"ServiceAccount/foo-sub",
+		"ConfigMap/foo-mychart-files",
+		"Service/foo-mychart",
+		"Deployment/foo-mychart",
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("Render() = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("Render() = %v, want %v", kinds, want)
+		}
+	}
+
+	deployment := resources[4]
+	if ns := deployment.GetNamespace(); ns != "bar" {
+		t.Errorf("Expected namespace 'bar', got %q", ns)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("Expected 3 replicas, got %d", replicas)
+	}
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]interface{})
+	if image := container["image"]; image != "nginx:1.25" {
+		t.Errorf("Expected image 'nginx:1.25', got %q", image)
+	}
+	env := container["env"].([]interface{})
+	if v := env[0].(map[string]interface{})["value"]; v != "api.example.com" {
+		t.Errorf("Expected tpl to render 'api.example.com', got %q", v)
+	}
+	if v := env[1].(map[string]interface{})["value"]; v != "hello" {
+		t.Errorf("Expected subchart default 'hello', got %q", v)
+	}
+	if label := deployment.GetLabels()["app.kubernetes.io/managed-by"]; label != "Helm" {
+		t.Errorf("Expected managed-by label 'Helm', got %q", label)
+	}
+
+	sa := resources[1].GetAnnotations()
+	if sa["domain"] != "example.com" {
+		t.Errorf("Expected parent global to win, got %q", sa["domain"])
+	}
+	if sa["template"] != "mychart/charts/sub/templates/serviceaccount.yaml" {
+		t.Errorf("Unexpected template name %q", sa["template"])
+	}
+
+	if a := resources[2].GetAnnotations(); a["literal"] != "<no value>" || a["missing"] != "" {
+		t.Errorf("Expected only missing values to be empty, got %v", a)
+	}
+
+	cm, _, _ := unstructured.NestedStringMap(resources[2].Object, "data")
+	if cm["app.conf"] != "listen 80\n" {
+		t.Errorf("Expected file content in ConfigMap, got %v", cm)
+	}
+	if len(cm) != 1 {
+		t.Errorf("Expected files matched by .helmignore to be excluded, got %v", cm)
+	}
+}
+
+func TestRenderConditions(t *testing.T) {
+	chart, err := Load("testdata/mychart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]interface{}{
+		"sub":      map[string]interface{}{"enabled": false},
+		"disabled": map[string]interface{}{"enabled": true},
+	}
+	resources, err := Render(chart, values, Options{KubeVersion: "1.19.4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, u := range resources {
+		found[u.GetKind()+"/"+u.GetName()] = true
+	}
+	if found["ServiceAccount/release-name-sub"] {
+		t.Error("Disabled subchart was rendered")
+	}
+	if !found["Pod/disabled"] {
+		t.Error("Enabled subchart wasn't rendered")
+	}
+	if !found["ConfigMap/legacy"] {
+		t.Error("Expected KubeVersion to satisfy semverCompare")
+	}
+}
+
+func TestRenderCRDs(t *testing.T) {
+	crd := func(name string) string {
+		return "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: " + name + "\n"
+	}
+	chart := &Chart{
+		Metadata: Metadata{Name: "crds"},
+		CRDs: []File{
+			{Name: "crds/both.yaml", Data: []byte(crd("a.example.com") + "---\n" + crd("b.example.com"))},
+		},
+		Templates: []File{
+			{Name: "templates/configmap.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")},
+			{Name: "templates/namespace.yaml", Data: []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: ns\n")},
+		},
+	}
+	resources, err := Render(chart, nil, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, u := range resources {
+		names = append(names, u.GetName())
+	}
+	if got, want := strings.Join(names, ","), "a.example.com,b.example.com,ns,config"; got != want {
+		t.Errorf("Render() = %s, want %s", got, want)
+	}
+
+	chart.CRDs = []File{{Name: "crds/empty.yaml", Data: []byte("# no documents\n")}}
+	chart.Templates = nil
+	if resources, err := Render(chart, nil, Options{}); err != nil || len(resources) != 0 {
+		t.Errorf("Render() = %v, %v, want no resources", resources, err)
+	}
+}
+
+func TestRenderKubeVersion(t *testing.T) {
+	chart, err := Load("testdata/mychart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Render(chart, nil, Options{KubeVersion: "v1.18.0"}); err == nil {
+		t.Error("Expected an error for an incompatible KubeVersion")
+	}
+	if _, err := Render(chart, nil, Options{KubeVersion: "v2.0.0"}); err == nil {
+		t.Error("Expected an error for a KubeVersion beyond a caret constraint")
+	}
+	chart.Metadata.KubeVersion = ">=1.19 <<1.30"
+	_, err = Render(chart, nil, Options{})
+	if err == nil || strings.Contains(err.Error(), "incompatible") {
+		t.Errorf("Expected an error for an invalid kubeVersion, got %v", err)
+	}
+}
+
+func TestLoadArchive(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	err := filepath.Walk("testdata/mychart", func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel("testdata", name)
+		hdr := &tar.Header{Name: filepath.ToSlash(rel), Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+	archive := filepath.Join(t.TempDir(), "mychart-0.1.0.tgz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chart, err := Load(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart.Metadata.Name != "mychart" || len(chart.Dependencies) != 2 {
+		t.Errorf("Failed to load archive: %+v", chart.Metadata)
+	}
+	if _, err := Render(chart, nil, Options{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMissingDependency(t *testing.T) {
+	dir := t.TempDir()
+	chart := "apiVersion: v2\nname: foo\nversion: 0.1.0\ndependencies:\n- name: bar\n  version: 1.0.0\n"
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chart), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Render(c, nil, Options{}); err == nil {
+		t.Error("Expected an error for a missing dependency")
+	}
+}
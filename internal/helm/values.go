@@ -0,0 +1,172 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// scope is a chart, or subchart, and the values used to render it
+type scope struct {
+	chart  *Chart
+	prefix string
+	values map[string]interface{}
+}
+
+// resolve coalesces vals with the chart's defaults and recursively
+// does the same for each of its enabled dependencies, whose
+// resulting values are nested in their parent's beneath their name
+// (or alias), with parent globals taking precedence.
+func resolve(c *Chart, vals map[string]interface{}, prefix string) ([]scope, error) {
+	vals = coalesce(vals, copyValues(c.Values))
+	result := []scope{{c, prefix, vals}}
+	subs, err := dependencies(c, vals)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		name := sub.Metadata.Name
+		subVals, _ := vals[name].(map[string]interface{})
+		if subVals == nil {
+			subVals = map[string]interface{}{}
+		}
+		parentGlobals, _ := vals["global"].(map[string]interface{})
+		globals, _ := subVals["global"].(map[string]interface{})
+		subVals["global"] = coalesce(copyValues(parentGlobals), globals)
+		scopes, err := resolve(sub, subVals, prefix+"/charts/"+name)
+		if err != nil {
+			return nil, err
+		}
+		vals[name] = scopes[0].values
+		result = append(result, scopes...)
+	}
+	return result, nil
+}
+
+// dependencies returns the enabled subcharts of c, named by their
+// aliases, if any
+func dependencies(c *Chart, vals map[string]interface{}) ([]*Chart, error) {
+	available := map[string]*Chart{}
+	for _, sub := range c.Dependencies {
+		available[sub.Metadata.Name] = sub
+	}
+	declared := map[string]bool{}
+	result := []*Chart{}
+	for _, dep := range c.Metadata.Dependencies {
+		sub, ok := available[dep.Name]
+		if !ok {
+			return nil, fmt.Errorf("chart %s: dependency %q not found in charts/", c.Metadata.Name, dep.Name)
+		}
+		declared[dep.Name] = true
+		if !enabled(dep, vals) {
+			continue
+		}
+		if dep.Alias != "" {
+			alias := *sub
+			alias.Metadata.Name = dep.Alias
+			sub = &alias
+		}
+		result = append(result, sub)
+	}
+	// undeclared subcharts are always enabled
+	for _, sub := range c.Dependencies {
+		if !declared[sub.Metadata.Name] {
+			result = append(result, sub)
+		}
+	}
+	return result, nil
+}
+
+// enabled evaluates the dependency's condition, falling back to its
+// tags, against the parent's values
+func enabled(dep Dependency, vals map[string]interface{}) bool {
+	for _, cond := range strings.Split(dep.Condition, ",") {
+		if cond = strings.TrimSpace(cond); cond == "" {
+			continue
+		}
+		if b, ok := lookup(vals, cond).(bool); ok {
+			return b
+		}
+	}
+	if len(dep.Tags) == 0 {
+		return true
+	}
+	tags, _ := vals["tags"].(map[string]interface{})
+	found := false
+	for _, tag := range dep.Tags {
+		if b, ok := tags[tag].(bool); ok {
+			if b {
+				return true
+			}
+			found = true
+		}
+	}
+	return !found
+}
+
+// lookup returns the value at a dot-delimited path
+func lookup(vals map[string]interface{}, path string) interface{} {
+	var v interface{} = vals
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// coalesce merges src into dst, with dst taking precedence. As in
+// Helm, a nil value in dst removes the key altogether.
+func coalesce(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+	for k, v := range src {
+		dv, ok := dst[k]
+		switch {
+		case !ok:
+			dst[k] = v
+		case dv == nil:
+			delete(dst, k)
+		default:
+			dm, dok := dv.(map[string]interface{})
+			sm, sok := v.(map[string]interface{})
+			if dok && sok {
+				dst[k] = coalesce(dm, sm)
+			}
+		}
+	}
+	for k, v := range dst {
+		if v == nil {
+			delete(dst, k)
+		}
+	}
+	return dst
+}
+
+// copyValues deep copies the maps and slices within vals
+func copyValues(vals map[string]interface{}) map[string]interface{} {
+	if vals == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(vals))
+	for k, v := range vals {
+		result[k] = copyValue(v)
+	}
+	return result
+}
+
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return copyValues(x)
+	case []interface{}:
+		result := make([]interface{}, len(x))
+		for i := range x {
+			result[i] = copyValue(x[i])
+		}
+		return result
+	}
+	return v
+}
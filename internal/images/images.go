@@ -0,0 +1,83 @@
+// Package images parses and compares container image references.
+package images
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Reference is a parsed container image reference
+type Reference struct {
+	Repository string // including any registry, e.g. gcr.io/project/app
+	Tag        string
+	Digest     string // e.g. sha256:...
+}
+
+// Parse splits an image reference into its repository, tag and
+// digest, any of which but the repository may be empty
+func Parse(image string) Reference {
+	ref := Reference{}
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, ref.Digest = image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, ref.Tag = image[:i], image[i+1:]
+	}
+	ref.Repository = image
+	return ref
+}
+
+// String joins the components of a reference
+func (r Reference) String() string {
+	result := r.Repository
+	if r.Tag != "" {
+		result += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		result += "@" + r.Digest
+	}
+	return result
+}
+
+// Registry returns the registry host of the repository, defaulting
+// to docker.io
+func (r Reference) Registry() string {
+	registry, _ := split(r.Repository)
+	return registry
+}
+
+// Normalize returns the fully-qualified form of a repository, e.g.
+// docker.io/library/nginx for nginx, so that equivalent repositories
+// may be compared
+func Normalize(repository string) string {
+	registry, path := split(repository)
+	if registry == "docker.io" && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return registry + "/" + path
+}
+
+// digest matches the sha256 and sha512 digests of image content
+var digest = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+
+// IsDigest returns true for a digest of image content, e.g.
+// sha256:0123...
+func IsDigest(s string) bool {
+	return digest.MatchString(s)
+}
+
+// split separates the registry host from the path of a repository
+func split(repository string) (string, string) {
+	i := strings.Index(repository, "/")
+	if i < 0 {
+		return "docker.io", repository
+	}
+	host := repository[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return "docker.io", repository
+	}
+	if host == "index.docker.io" {
+		host = "docker.io"
+	}
+	return host, repository[i+1:]
+}
@@ -0,0 +1,69 @@
+package images_test
+
+import (
+	"testing"
+
+	. "github.com/manifestival/manifestival/internal/images"
+)
+
+const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Repository: "nginx"}},
+		{"nginx:1.25", Reference{Repository: "nginx", Tag: "1.25"}},
+		{"localhost:5000/app", Reference{Repository: "localhost:5000/app"}},
+		{"localhost:5000/app:v1", Reference{Repository: "localhost:5000/app", Tag: "v1"}},
+		{"gcr.io/project/app@" + digest, Reference{Repository: "gcr.io/project/app", Digest: digest}},
+		{"gcr.io/project/app:v1@" + digest, Reference{Repository: "gcr.io/project/app", Tag: "v1", Digest: digest}},
+	}
+	for _, test := range tests {
+		got := Parse(test.image)
+		if got != test.want {
+			t.Errorf("Parse(%q) = %#v, want %#v", test.image, got, test.want)
+		}
+		if got.String() != test.image {
+			t.Errorf("String() = %q, want %q", got.String(), test.image)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                         "docker.io/library/nginx",
+		"library/nginx":                 "docker.io/library/nginx",
+		"docker.io/nginx":               "docker.io/library/nginx",
+		"index.docker.io/library/nginx": "docker.io/library/nginx",
+		"bitnami/redis":                 "docker.io/bitnami/redis",
+		"gcr.io/project/app":            "gcr.io/project/app",
+		"localhost/app":                 "localhost/app",
+		"registry.local:5000/team/app":  "registry.local:5000/team/app",
+	}
+	for repository, want := range tests {
+		if got := Normalize(repository); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", repository, got, want)
+		}
+	}
+	if got := Parse("registry.local:5000/app:v1").Registry(); got != "registry.local:5000" {
+		t.Errorf("Registry() = %q", got)
+	}
+}
+
+func TestIsDigest(t *testing.T) {
+	tests := map[string]bool{
+		digest:                              true,
+		"@" + digest:                        false,
+		"sha256:0123":                       false,
+		"sha512:" + digest[7:]:              false,
+		"sha512:" + digest[7:] + digest[7:]: true,
+		"latest":                            false,
+	}
+	for s, want := range tests {
+		if got := IsDigest(s); got != want {
+			t.Errorf("IsDigest(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
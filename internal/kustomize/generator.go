@@ -0,0 +1,298 @@
+package kustomize
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Generator describes a ConfigMap or Secret built from files,
+// literals and env files
+type Generator struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Behavior  string            `json:"behavior,omitempty"`
+	Type      string            `json:"type,omitempty"`
+	Files     []string          `json:"files,omitempty"`
+	Literals  []string          `json:"literals,omitempty"`
+	Envs      []string          `json:"envs,omitempty"`
+	Env       string            `json:"env,omitempty"`
+	Options   *GeneratorOptions `json:"options,omitempty"`
+}
+
+// GeneratorOptions apply to generated resources
+type GeneratorOptions struct {
+	Labels                map[string]string `json:"labels,omitempty"`
+	Annotations           map[string]string `json:"annotations,omitempty"`
+	DisableNameSuffixHash bool              `json:"disableNameSuffixHash,omitempty"`
+	Immutable             bool              `json:"immutable,omitempty"`
+}
+
+// NeedsHash annotates generated resources whose names should have a
+// content hash appended once all transformations are done
+const NeedsHash = "kustomize.config.k8s.io/needs-hash"
+
+// ConfigMap builds the ConfigMap described by g, with relative paths
+// resolved against dir. The global options are overridden by those
+// of the generator.
+func (g Generator) ConfigMap(dir string, global *GeneratorOptions) (*unstructured.Unstructured, error) {
+	data, err := g.data(dir)
+	if err != nil {
+		return nil, err
+	}
+	u := ConfigMap(g.Name, data)
+	g.apply(u, global)
+	return u, nil
+}
+
+// Secret builds the Secret described by g, with relative paths
+// resolved against dir. The global options are overridden by those
+// of the generator.
+func (g Generator) Secret(dir string, global *GeneratorOptions) (*unstructured.Unstructured, error) {
+	data, err := g.data(dir)
+	if err != nil {
+		return nil, err
+	}
+	u := Secret(g.Name, g.Type, data)
+	g.apply(u, global)
+	return u, nil
+}
+
+// ConfigMap returns a ConfigMap containing data, with non-UTF-8
+// values stored as binaryData
+func ConfigMap(name string, data map[string][]byte) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetName(name)
+	text := map[string]interface{}{}
+	binary := map[string]interface{}{}
+	for k, v := range data {
+		if utf8.Valid(v) {
+			text[k] = string(v)
+		} else {
+			binary[k] = base64.StdEncoding.EncodeToString(v)
+		}
+	}
+	if len(text) > 0 {
+		u.Object["data"] = text
+	}
+	if len(binary) > 0 {
+		u.Object["binaryData"] = binary
+	}
+	return u
+}
+
+// Secret returns a Secret of the given type, Opaque by default,
+// containing data
+func Secret(name, typ string, data map[string][]byte) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("Secret")
+	u.SetName(name)
+	if typ == "" {
+		typ = "Opaque"
+	}
+	u.Object["type"] = typ
+	encoded := map[string]interface{}{}
+	for k, v := range data {
+		encoded[k] = base64.StdEncoding.EncodeToString(v)
+	}
+	if len(encoded) > 0 {
+		u.Object["data"] = encoded
+	}
+	return u
+}
+
+// Hash returns a hash of the content of a ConfigMap or Secret,
+// compatible with the name suffix kustomize would generate
+func Hash(u *unstructured.Unstructured) (string, error) {
+	m := map[string]interface{}{
+		"kind": u.GetKind(),
+		"name": u.GetName(),
+	}
+	switch u.GetKind() {
+	case "ConfigMap":
+		m["data"] = map[string]interface{}{}
+		if data, ok := u.Object["data"]; ok {
+			m["data"] = data
+		}
+		if binary, ok := u.Object["binaryData"]; ok {
+			m["binaryData"] = binary
+		}
+	case "Secret":
+		m["type"] = u.Object["type"]
+		m["data"] = map[string]interface{}{}
+		if data, ok := u.Object["data"]; ok {
+			m["data"] = data
+		}
+	default:
+		return "", fmt.Errorf("cannot hash a %s", u.GetKind())
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return encode(fmt.Sprintf("%x", sha256.Sum256(data))), nil
+}
+
+// encode shortens a hex hash, replacing characters likely to form
+// words, as kustomize does
+func encode(hex string) string {
+	enc := []rune(hex[:10])
+	for i := range enc {
+		switch enc[i] {
+		case '0':
+			enc[i] = 'g'
+		case '1':
+			enc[i] = 'h'
+		case '3':
+			enc[i] = 'k'
+		case 'a':
+			enc[i] = 'm'
+		case 'e':
+			enc[i] = 't'
+		}
+	}
+	return string(enc)
+}
+
+// apply sets the namespace and options of a generated resource
+func (g Generator) apply(u *unstructured.Unstructured, global *GeneratorOptions) {
+	if g.Namespace != "" {
+		u.SetNamespace(g.Namespace)
+	}
+	opts := GeneratorOptions{}
+	for _, o := range []*GeneratorOptions{global, g.Options} {
+		if o == nil {
+			continue
+		}
+		opts.Labels = merge(opts.Labels, o.Labels)
+		opts.Annotations = merge(opts.Annotations, o.Annotations)
+		opts.DisableNameSuffixHash = opts.DisableNameSuffixHash || o.DisableNameSuffixHash
+		opts.Immutable = opts.Immutable || o.Immutable
+	}
+	if len(opts.Labels) > 0 {
+		u.SetLabels(opts.Labels)
+	}
+	annotations := opts.Annotations
+	if !opts.DisableNameSuffixHash {
+		annotations = merge(annotations, map[string]string{NeedsHash: "true"})
+	}
+	if len(annotations) > 0 {
+		u.SetAnnotations(annotations)
+	}
+	if opts.Immutable {
+		u.Object["immutable"] = true
+	}
+}
+
+// data collects the generator's literals, files and env files
+func (g Generator) data(dir string) (map[string][]byte, error) {
+	result := map[string][]byte{}
+	add := func(k string, v []byte) error {
+		if _, ok := result[k]; ok {
+			return fmt.Errorf("generator %s: duplicate key %q", g.Name, k)
+		}
+		result[k] = v
+		return nil
+	}
+	envs := append([]string{}, g.Envs...)
+	if g.Env != "" {
+		envs = append(envs, g.Env)
+	}
+	for _, env := range envs {
+		data, err := os.ReadFile(resolve(dir, env))
+		if err != nil {
+			return nil, err
+		}
+		pairs, err := ParseEnv(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", env, err)
+		}
+		for _, kv := range pairs {
+			if err := add(kv[0], []byte(kv[1])); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, file := range g.Files {
+		key, path, found := strings.Cut(file, "=")
+		if !found {
+			key, path = filepath.Base(file), file
+		}
+		data, err := os.ReadFile(resolve(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		if err := add(key, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, literal := range g.Literals {
+		key, value, found := strings.Cut(literal, "=")
+		if !found {
+			return nil, fmt.Errorf("generator %s: invalid literal %q", g.Name, literal)
+		}
+		if err := add(key, []byte(unquote(value))); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// ParseEnv parses KEY=VALUE lines, ignoring blank lines and comments
+func ParseEnv(data []byte) ([][2]string, error) {
+	result := [][2]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("line %d: invalid env %q", n, line)
+		}
+		result = append(result, [2]string{strings.TrimSpace(key), unquote(value)})
+	}
+	return result, scanner.Err()
+}
+
+// unquote removes a single pair of matching quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// resolve returns path relative to dir, unless it's absolute
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func merge(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]string{}
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -0,0 +1,76 @@
+package kustomize_test
+
+import (
+	"testing"
+
+	. "github.com/manifestival/manifestival/internal/kustomize"
+)
+
+// The expected values are those of kustomize's own hasher tests
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   string
+		data   map[string][]byte
+		expect string
+	}{{
+		name:   "empty ConfigMap",
+		kind:   "ConfigMap",
+		data:   map[string][]byte{},
+		expect: "42745tchd9",
+	}, {
+		name:   "one key ConfigMap",
+		kind:   "ConfigMap",
+		data:   map[string][]byte{"one": []byte("")},
+		expect: "9g67k2htb6",
+	}, {
+		name:   "three key ConfigMap",
+		kind:   "ConfigMap",
+		data:   map[string][]byte{"two": []byte("2"), "one": []byte(""), "three": []byte("3")},
+		expect: "f5h7t85m9b",
+	}, {
+		name:   "empty Secret",
+		kind:   "Secret",
+		data:   map[string][]byte{},
+		expect: "t75bgf6ctb",
+	}, {
+		name:   "one key Secret",
+		kind:   "Secret",
+		data:   map[string][]byte{"one": []byte("")},
+		expect: "74bd68bm66",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := ConfigMap("", test.data)
+			if test.kind == "Secret" {
+				u = Secret("", "my-type", test.data)
+			}
+			actual, err := Hash(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != test.expect {
+				t.Errorf("Hash() = %q, want %q", actual, test.expect)
+			}
+		})
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	pairs, err := ParseEnv([]byte("# comment\n\nFOO=bar\nQUOTED=\"a b\"\nEMPTY=\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{{"FOO", "bar"}, {"QUOTED", "a b"}, {"EMPTY", ""}}
+	if len(pairs) != len(want) {
+		t.Fatalf("ParseEnv() = %v, want %v", pairs, want)
+	}
+	for i := range want {
+		if pairs[i] != want[i] {
+			t.Errorf("ParseEnv() = %v, want %v", pairs, want)
+		}
+	}
+	if _, err := ParseEnv([]byte("INVALID")); err == nil {
+		t.Error("Expected an error for a line lacking '='")
+	}
+}
@@ -0,0 +1,98 @@
+package kustomize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Filenames are the names recognized as a kustomization file, in
+// order of precedence
+var Filenames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// Kustomization is the supported subset of a kustomization file.
+// Unsupported fields are rejected when loaded.
+type Kustomization struct {
+	APIVersion            string            `json:"apiVersion,omitempty"`
+	Kind                  string            `json:"kind,omitempty"`
+	Resources             []string          `json:"resources,omitempty"`
+	Bases                 []string          `json:"bases,omitempty"`
+	Namespace             string            `json:"namespace,omitempty"`
+	NamePrefix            string            `json:"namePrefix,omitempty"`
+	NameSuffix            string            `json:"nameSuffix,omitempty"`
+	CommonLabels          map[string]string `json:"commonLabels,omitempty"`
+	CommonAnnotations     map[string]string `json:"commonAnnotations,omitempty"`
+	Patches               []Patch           `json:"patches,omitempty"`
+	PatchesStrategicMerge []string          `json:"patchesStrategicMerge,omitempty"`
+	PatchesJson6902       []Patch           `json:"patchesJson6902,omitempty"`
+	ConfigMapGenerator    []Generator       `json:"configMapGenerator,omitempty"`
+	SecretGenerator       []Generator       `json:"secretGenerator,omitempty"`
+	GeneratorOptions      *GeneratorOptions `json:"generatorOptions,omitempty"`
+	Images                []Image           `json:"images,omitempty"`
+	Replicas              []Replicas        `json:"replicas,omitempty"`
+	Labels                []Labels          `json:"labels,omitempty"`
+}
+
+// Image overrides the name, tag or digest of the images of containers
+// whose repository is Name
+type Image struct {
+	Name    string `json:"name"`
+	NewName string `json:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+// Replicas sets the replicas of the workload named Name
+type Replicas struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// Labels are added to the metadata of every resource, and optionally
+// to their templates and selectors
+type Labels struct {
+	Pairs            map[string]string `json:"pairs"`
+	IncludeSelectors bool              `json:"includeSelectors,omitempty"`
+	IncludeTemplates bool              `json:"includeTemplates,omitempty"`
+}
+
+// Patch is either a strategic merge or a JSON 6902 patch, specified
+// inline or by path, applied to the resources matching Target
+type Patch struct {
+	Path   string    `json:"path,omitempty"`
+	Patch  string    `json:"patch,omitempty"`
+	Target *Selector `json:"target,omitempty"`
+}
+
+// Selector identifies the targets of a Patch. Name and Namespace are
+// anchored regular expressions.
+type Selector struct {
+	Group              string `json:"group,omitempty"`
+	Version            string `json:"version,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Namespace          string `json:"namespace,omitempty"`
+	LabelSelector      string `json:"labelSelector,omitempty"`
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
+}
+
+// Load reads the kustomization file in dir
+func Load(dir string) (*Kustomization, error) {
+	for _, name := range Filenames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result := &Kustomization{}
+		if err := yaml.UnmarshalStrict(data, result); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, name), err)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("no kustomization file found in %s", dir)
+}
@@ -0,0 +1,127 @@
+package kustomize
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fieldSpec locates a map of labels or annotations within resources
+// of a kind (any kind, if empty). Path segments ending in "[]" denote
+// lists, each element of which is traversed. If create is false, the
+// map is only updated if it already exists.
+type fieldSpec struct {
+	kind   string
+	path   string
+	create bool
+}
+
+// templateLabels are the labels of templates for pods and volume claims
+var templateLabels = []fieldSpec{
+	{"ReplicationController", "spec.template.metadata.labels", true},
+	{"Deployment", "spec.template.metadata.labels", true},
+	{"ReplicaSet", "spec.template.metadata.labels", true},
+	{"DaemonSet", "spec.template.metadata.labels", true},
+	{"StatefulSet", "spec.template.metadata.labels", true},
+	{"StatefulSet", "spec.volumeClaimTemplates[].metadata.labels", true},
+	{"Job", "spec.template.metadata.labels", true},
+	{"CronJob", "spec.jobTemplate.metadata.labels", true},
+	{"CronJob", "spec.jobTemplate.spec.template.metadata.labels", true},
+}
+
+// selectorLabels are the label selectors of resources targeting pods,
+// most of which are immutable
+var selectorLabels = []fieldSpec{
+	{"Service", "spec.selector", true},
+	{"ReplicationController", "spec.selector", true},
+	{"Deployment", "spec.selector.matchLabels", true},
+	{"ReplicaSet", "spec.selector.matchLabels", true},
+	{"DaemonSet", "spec.selector.matchLabels", true},
+	{"StatefulSet", "spec.selector.matchLabels", true},
+	{"Job", "spec.selector.matchLabels", false},
+	{"CronJob", "spec.jobTemplate.spec.selector.matchLabels", false},
+	{"PodDisruptionBudget", "spec.selector.matchLabels", false},
+	{"NetworkPolicy", "spec.podSelector.matchLabels", false},
+	{"NetworkPolicy", "spec.ingress[].from[].podSelector.matchLabels", false},
+	{"NetworkPolicy", "spec.egress[].to[].podSelector.matchLabels", false},
+}
+
+// templateAnnotations are the annotations of pod templates
+var templateAnnotations = []fieldSpec{
+	{"ReplicationController", "spec.template.metadata.annotations", true},
+	{"Deployment", "spec.template.metadata.annotations", true},
+	{"ReplicaSet", "spec.template.metadata.annotations", true},
+	{"DaemonSet", "spec.template.metadata.annotations", true},
+	{"StatefulSet", "spec.template.metadata.annotations", true},
+	{"Job", "spec.template.metadata.annotations", true},
+	{"CronJob", "spec.jobTemplate.metadata.annotations", true},
+	{"CronJob", "spec.jobTemplate.spec.template.metadata.annotations", true},
+}
+
+// AddLabels adds labels to the metadata of u, and optionally to its
+// pod and volume claim templates and its label selectors. Kustomize's
+// commonLabels does all three.
+func AddLabels(u *unstructured.Unstructured, labels map[string]string, templates, selectors bool) {
+	specs := []fieldSpec{{"", "metadata.labels", true}}
+	if templates {
+		specs = append(specs, templateLabels...)
+	}
+	if selectors {
+		specs = append(specs, selectorLabels...)
+	}
+	add(u, labels, specs)
+}
+
+// AddAnnotations adds annotations to the metadata of u, and optionally
+// to its pod templates. Kustomize's commonAnnotations does both.
+func AddAnnotations(u *unstructured.Unstructured, annotations map[string]string, templates bool) {
+	specs := []fieldSpec{{"", "metadata.annotations", true}}
+	if templates {
+		specs = append(specs, templateAnnotations...)
+	}
+	add(u, annotations, specs)
+}
+
+func add(u *unstructured.Unstructured, values map[string]string, specs []fieldSpec) {
+	if len(values) == 0 {
+		return
+	}
+	for _, spec := range specs {
+		if spec.kind != "" && spec.kind != u.GetKind() {
+			continue
+		}
+		visit(u.Object, strings.Split(spec.path, "."), spec.create, func(m map[string]interface{}) {
+			for k, v := range values {
+				m[k] = v
+			}
+		})
+	}
+}
+
+// visit calls fn with the map at path, creating it (but not any
+// lists along the way) if create is true
+func visit(obj map[string]interface{}, path []string, create bool, fn func(map[string]interface{})) {
+	if len(path) == 0 {
+		fn(obj)
+		return
+	}
+	field := path[0]
+	if strings.HasSuffix(field, "[]") {
+		list, _ := obj[strings.TrimSuffix(field, "[]")].([]interface{})
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				visit(m, path[1:], create, fn)
+			}
+		}
+		return
+	}
+	next, ok := obj[field].(map[string]interface{})
+	if !ok {
+		if !create || obj[field] != nil {
+			return
+		}
+		next = map[string]interface{}{}
+		obj[field] = next
+	}
+	visit(next, path[1:], create, fn)
+}
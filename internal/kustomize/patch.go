@@ -0,0 +1,156 @@
+package kustomize
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	mergepatch "github.com/manifestival/manifestival/internal/patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+)
+
+// Content returns the patch, either inline or read from its path
+// relative to dir
+func (p Patch) Content(dir string) ([]byte, error) {
+	if p.Patch != "" {
+		return []byte(p.Patch), nil
+	}
+	if p.Path == "" {
+		return nil, fmt.Errorf("patch requires either a path or inline content")
+	}
+	return os.ReadFile(resolve(dir, p.Path))
+}
+
+// Decode returns either a JSON 6902 patch or, if the content is a
+// YAML/JSON object, a strategic merge patch
+func Decode(data []byte) (jsonpatch.Patch, map[string]interface{}, error) {
+	js, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var v interface{}
+	if err := utiljson.Unmarshal(js, &v); err != nil {
+		return nil, nil, err
+	}
+	switch x := v.(type) {
+	case []interface{}:
+		js, err := json.Marshal(x)
+		if err != nil {
+			return nil, nil, err
+		}
+		ops, err := jsonpatch.DecodePatch(js)
+		return ops, nil, err
+	case map[string]interface{}:
+		return nil, x, nil
+	}
+	return nil, nil, fmt.Errorf("patch must be either a list of operations or an object")
+}
+
+// ApplyJSON applies an RFC 6902 patch to u
+func ApplyJSON(u *unstructured.Unstructured, ops jsonpatch.Patch) error {
+	doc, err := u.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if doc, err = ops.Apply(doc); err != nil {
+		return fmt.Errorf("%s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+	return u.UnmarshalJSON(doc)
+}
+
+// ApplyStrategic applies a strategic merge patch to u, using the
+// patch metadata of its registered type, and falling back to an RFC
+// 7386 merge patch otherwise. It returns false if the patch deleted
+// the resource via `$patch: delete`.
+func ApplyStrategic(u *unstructured.Unstructured, patch map[string]interface{}) (bool, error) {
+	if patch["$patch"] == "delete" {
+		return false, nil
+	}
+	p, err := json.Marshal(patch)
+	if err != nil {
+		return false, err
+	}
+	if err := mergepatch.Strategic(u, p); err != nil {
+		return false, fmt.Errorf("%s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+	return true, nil
+}
+
+// WithoutIdentity returns a copy of a strategic merge patch without
+// the apiVersion, kind, name and namespace identifying its target,
+// which would otherwise be merged into it
+func WithoutIdentity(patch map[string]interface{}) map[string]interface{} {
+	patch = runtime.DeepCopyJSON(patch)
+	if metadata, ok := patch["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "name")
+		delete(metadata, "namespace")
+	}
+	delete(patch, "apiVersion")
+	delete(patch, "kind")
+	return patch
+}
+
+// Matches returns true if u is selected by s
+func (s *Selector) Matches(u *unstructured.Unstructured) (bool, error) {
+	gvk := u.GroupVersionKind()
+	if (s.Group != "" && s.Group != gvk.Group) ||
+		(s.Version != "" && s.Version != gvk.Version) ||
+		(s.Kind != "" && s.Kind != gvk.Kind) {
+		return false, nil
+	}
+	for _, x := range []struct{ pattern, value string }{{s.Name, u.GetName()}, {s.Namespace, u.GetNamespace()}} {
+		if x.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + x.pattern + ")$")
+		if err != nil {
+			return false, err
+		}
+		if !re.MatchString(x.value) {
+			return false, nil
+		}
+	}
+	for _, x := range []struct {
+		selector string
+		set      map[string]string
+	}{{s.LabelSelector, u.GetLabels()}, {s.AnnotationSelector, u.GetAnnotations()}} {
+		if x.selector == "" {
+			continue
+		}
+		sel, err := labels.Parse(x.selector)
+		if err != nil {
+			return false, err
+		}
+		if !sel.Matches(labels.Set(x.set)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SelectorFor returns a Selector matching only the resource
+// identified by the apiVersion, kind and metadata of a patch
+func SelectorFor(patch map[string]interface{}) (*Selector, error) {
+	u := unstructured.Unstructured{Object: patch}
+	if u.GetKind() == "" || u.GetName() == "" {
+		return nil, fmt.Errorf("patch lacks either a kind or name to identify its target")
+	}
+	gv, err := schema.ParseGroupVersion(u.GetAPIVersion())
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{
+		Group:     gv.Group,
+		Version:   gv.Version,
+		Kind:      u.GetKind(),
+		Name:      regexp.QuoteMeta(u.GetName()),
+		Namespace: regexp.QuoteMeta(u.GetNamespace()),
+	}, nil
+}
@@ -0,0 +1,250 @@
+// Package oci reads artifacts from OCI image layouts, either
+// directories or tarballs, as described by
+// https://github.com/opencontainers/image-spec/blob/main/image-layout.md
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/manifestival/manifestival/internal/sources"
+)
+
+const (
+	// MediaTypeIndex is the media type of an image index
+	MediaTypeIndex = "application/vnd.oci.image.index.v1+json"
+	// MediaTypeManifest is the media type of an image manifest
+	MediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	// RefName is the annotation naming a manifest within an index
+	RefName = "org.opencontainers.image.ref.name"
+)
+
+// Descriptor references a blob by its digest
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Index lists the manifests in a layout
+type Index struct {
+	MediaType string       `json:"mediaType,omitempty"`
+	Manifests []Descriptor `json:"manifests"`
+}
+
+// Manifest lists the layers of an image or artifact
+type Manifest struct {
+	MediaType string       `json:"mediaType,omitempty"`
+	Config    Descriptor   `json:"config"`
+	Layers    []Descriptor `json:"layers"`
+}
+
+// Layout is an OCI image layout
+type Layout struct {
+	read func(name string) ([]byte, error)
+}
+
+// Open returns the layout in the directory or tarball at path
+func Open(pathname string) (*Layout, error) {
+	info, err := os.Stat(pathname)
+	if err != nil {
+		return nil, err
+	}
+	l := &Layout{}
+	if info.IsDir() {
+		l.read = func(name string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(pathname, filepath.FromSlash(name)))
+		}
+	} else {
+		data, err := os.ReadFile(pathname)
+		if err != nil {
+			return nil, err
+		}
+		files, err := sources.Unpack(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pathname, err)
+		}
+		l.read = func(name string) ([]byte, error) {
+			if data, ok := files[name]; ok {
+				return data, nil
+			}
+			return nil, fmt.Errorf("%s: %s not found", pathname, name)
+		}
+	}
+	if _, err := l.read("oci-layout"); err != nil {
+		return nil, fmt.Errorf("%s: not an OCI image layout: %w", pathname, err)
+	}
+	return l, nil
+}
+
+// Resolve returns the manifest in the layout's index, or any nested
+// index, whose ref name annotation or digest matches ref. If ref is
+// empty, the index must contain exactly one manifest. A ref of the
+// form name:tag also matches a manifest whose ref name is the tag.
+func (l *Layout) Resolve(ref string) (*Manifest, error) {
+	data, err := l.read("index.json")
+	if err != nil {
+		return nil, err
+	}
+	index := &Index{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("index.json: %w", err)
+	}
+	found, err := l.find(index, ref, 0)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(found) == 0 && ref == "":
+		return nil, fmt.Errorf("index.json contains no manifests")
+	case len(found) == 0:
+		return nil, fmt.Errorf("no manifest matches %q", ref)
+	case len(found) > 1 && ref == "":
+		return nil, fmt.Errorf("index.json contains %d manifests; a reference is required", len(found))
+	case len(found) > 1:
+		return nil, fmt.Errorf("%d manifests match %q", len(found), ref)
+	}
+	if data, err = l.Blob(found[0]); err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", found[0].Digest, err)
+	}
+	return manifest, nil
+}
+
+// find returns the descriptors of the manifests matching ref
+func (l *Layout) find(index *Index, ref string, depth int) ([]Descriptor, error) {
+	if depth > 4 {
+		return nil, fmt.Errorf("too many nested indexes")
+	}
+	result := []Descriptor{}
+	for _, d := range index.Manifests {
+		if d.MediaType == MediaTypeIndex {
+			data, err := l.Blob(d)
+			if err != nil {
+				return nil, err
+			}
+			nested := &Index{}
+			if err := json.Unmarshal(data, nested); err != nil {
+				return nil, fmt.Errorf("%s: %w", d.Digest, err)
+			}
+			// A ref naming the nested index selects all its manifests
+			nestedRef := ref
+			if matches(d, ref) {
+				nestedRef = ""
+			}
+			found, err := l.find(nested, nestedRef, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, found...)
+			continue
+		}
+		if ref == "" || matches(d, ref) {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+func matches(d Descriptor, ref string) bool {
+	if ref == "" {
+		return false
+	}
+	name := d.Annotations[RefName]
+	if d.Digest == ref || name == ref {
+		return true
+	}
+	i := strings.LastIndex(ref, ":")
+	return name != "" && i > strings.LastIndex(ref, "/") && name == ref[i+1:]
+}
+
+// Blob returns the content of a blob, verifying its digest and size
+func (l *Layout) Blob(d Descriptor) ([]byte, error) {
+	algorithm, encoded, ok := strings.Cut(d.Digest, ":")
+	if !ok || algorithm != "sha256" || len(encoded) != 64 || strings.ContainsAny(encoded, "./") {
+		return nil, fmt.Errorf("unsupported digest %q", d.Digest)
+	}
+	data, err := l.read(path.Join("blobs", algorithm, encoded))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != encoded {
+		return nil, fmt.Errorf("blob %s: digest mismatch", d.Digest)
+	}
+	if d.Size != 0 && d.Size != int64(len(data)) {
+		return nil, fmt.Errorf("blob %s: size mismatch", d.Digest)
+	}
+	return data, nil
+}
+
+// Select returns the layers of m whose media types match any of the
+// patterns, as understood by path.Match, or all of them if there are
+// no patterns
+func (m *Manifest) Select(patterns ...string) []Descriptor {
+	result := []Descriptor{}
+	for _, layer := range m.Layers {
+		if len(patterns) == 0 {
+			result = append(result, layer)
+			continue
+		}
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, layer.MediaType); ok {
+				result = append(result, layer)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// IsArchive returns true if a media type denotes a tar or zip
+// archive, optionally compressed, e.g.
+// application/vnd.oci.image.layer.v1.tar+gzip
+func IsArchive(mediaType string) bool {
+	base, _, _ := strings.Cut(mediaType, "+")
+	for _, suffix := range []string{".tar", "/x-tar", "/tar", ".zip", "/zip"} {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read returns the manifests within the layers of the manifest
+// identified by ref whose media types match any of the patterns. The
+// YAML and JSON files within archive layers are returned, ordered by
+// path, while other layers are returned whole.
+func (l *Layout) Read(ref string, patterns ...string) ([][]byte, error) {
+	manifest, err := l.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	result := [][]byte{}
+	for _, layer := range manifest.Select(patterns...) {
+		data, err := l.Blob(layer)
+		if err != nil {
+			return nil, err
+		}
+		if !IsArchive(layer.MediaType) {
+			result = append(result, data)
+			continue
+		}
+		docs, err := sources.ReadArchive(data)
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+		result = append(result, docs...)
+	}
+	return result, nil
+}
@@ -0,0 +1,152 @@
+package oci_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/manifestival/manifestival/internal/oci"
+)
+
+const (
+	v1 = "sha256:bef11bc1dfcda1327d9f8ee1d3416f032c4f13b91a1edaf3e5cb887719759dd5"
+	v2 = "sha256:da3bcfb04f93274da0a7da857ca9e7ea7de2c33f5021231707d1abab22300b9e"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    string
+		ref       string
+		patterns  []string
+		want      []string
+		wantError string
+	}{{
+		name:   "tag",
+		layout: "testdata/oci",
+		ref:    "v1",
+		want:   []string{"Deployment", "Service", "CustomResourceDefinition"},
+	}, {
+		name:   "tarball",
+		layout: "testdata/oci.tar",
+		ref:    "v1",
+		want:   []string{"Deployment", "Service", "CustomResourceDefinition"},
+	}, {
+		name:   "image reference",
+		layout: "testdata/oci",
+		ref:    "example.com:5000/app:v2",
+		want:   []string{"CustomResourceDefinition"},
+	}, {
+		name:   "digest",
+		layout: "testdata/oci.tar",
+		ref:    v2,
+		want:   []string{"CustomResourceDefinition"},
+	}, {
+		name:     "media type",
+		layout:   "testdata/oci",
+		ref:      "v1",
+		patterns: []string{"application/vnd.cncf.flux.*"},
+		want:     []string{"Deployment", "Service"},
+	}, {
+		name:     "unmatched media type",
+		layout:   "testdata/oci",
+		ref:      "v1",
+		patterns: []string{"application/x-yaml"},
+		want:     []string{},
+	}, {
+		name:      "ambiguous",
+		layout:    "testdata/oci",
+		wantError: "a reference is required",
+	}, {
+		name:      "unknown",
+		layout:    "testdata/oci",
+		ref:       "v3",
+		wantError: `no manifest matches "v3"`,
+	}, {
+		name:      "not a layout",
+		layout:    "testdata",
+		wantError: "not an OCI image layout",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			docs, err := read(test.layout, test.ref, test.patterns...)
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("Read() = %v, wanted error containing %q", err, test.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			kinds := []string{}
+			for _, doc := range docs {
+				for _, line := range strings.Split(string(doc), "\n") {
+					if strings.HasPrefix(line, "kind: ") {
+						kinds = append(kinds, strings.TrimPrefix(line, "kind: "))
+					}
+				}
+			}
+			if strings.Join(kinds, ",") != strings.Join(test.want, ",") {
+				t.Errorf("Read() = %v, want %v", kinds, test.want)
+			}
+		})
+	}
+}
+
+func TestTamperedBlob(t *testing.T) {
+	dir := t.TempDir()
+	err := filepath.Walk("testdata/oci/", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, strings.TrimPrefix(v2, "sha256:")) {
+			data = append(data, ' ')
+		}
+		target := filepath.Join(dir, strings.TrimPrefix(path, "testdata/oci/"))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read(dir, "v1"); err != nil {
+		t.Error("Unexpected error reading an untampered manifest:", err)
+	}
+	if _, err := read(dir, "v2"); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Error("Expected a digest mismatch, got", err)
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	for mediaType, want := range map[string]bool{
+		"application/vnd.oci.image.layer.v1.tar":         true,
+		"application/vnd.oci.image.layer.v1.tar+gzip":    true,
+		"application/vnd.cncf.flux.content.v1.tar+gzip":  true,
+		"application/x-tar":                              true,
+		"application/zip":                                true,
+		"application/vnd.example.crds.v1+yaml":           false,
+		"application/yaml":                               false,
+		"application/vnd.cncf.helm.config.v1+json":       false,
+		"application/vnd.example.starred.v1+tar.extra+x": false,
+	} {
+		if got := IsArchive(mediaType); got != want {
+			t.Errorf("IsArchive(%q) = %v, want %v", mediaType, got, want)
+		}
+	}
+}
+
+func read(layout, ref string, patterns ...string) ([][]byte, error) {
+	l, err := Open(layout)
+	if err != nil {
+		return nil, err
+	}
+	return l.Read(ref, patterns...)
+}
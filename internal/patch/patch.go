@@ -6,6 +6,7 @@ import (
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -27,17 +28,41 @@ func New(curr, mod *unstructured.Unstructured, lastAppliedConfigAnnotation strin
 	if current, err = curr.MarshalJSON(); err != nil {
 		return
 	}
-	obj, err := scheme.Scheme.New(mod.GroupVersionKind())
+	meta, err := Schema(mod.GroupVersionKind())
 	switch {
-	case runtime.IsNotRegisteredError(err):
-		return createJsonMergePatch(original, modified, current)
 	case err != nil:
 		return
+	case meta == nil:
+		return createJsonMergePatch(original, modified, current)
 	default:
-		return createStrategicMergePatch(original, modified, current, obj)
+		return createStrategicMergePatch(original, modified, current, meta)
 	}
 }
 
+// Schema returns the strategic merge patch metadata of the type
+// registered for gvk, or nil if there is none, in which case an
+// RFC-7386 merge patch should be used
+func Schema(gvk schema.GroupVersionKind) (strategicpatch.LookupPatchMeta, error) {
+	obj, err := scheme.Scheme.New(gvk)
+	switch {
+	case runtime.IsNotRegisteredError(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return strategicpatch.NewPatchMetaFromStruct(obj)
+}
+
+// Strategic applies a strategic merge patch to obj, falling back to
+// RFC-7386 if its type isn't registered
+func Strategic(obj *unstructured.Unstructured, patch []byte) error {
+	meta, err := Schema(obj.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	return (&Patch{patch, meta}).Merge(obj)
+}
+
 // Apply the patch to the resource
 func (p *Patch) Merge(obj *unstructured.Unstructured) (err error) {
 	var current, result []byte
@@ -64,11 +89,7 @@ func createJsonMergePatch(original, modified, current []byte) (*Patch, error) {
 	return create(patch, nil), err
 }
 
-func createStrategicMergePatch(original, modified, current []byte, obj runtime.Object) (*Patch, error) {
-	schema, err := strategicpatch.NewPatchMetaFromStruct(obj)
-	if err != nil {
-		return nil, err
-	}
+func createStrategicMergePatch(original, modified, current []byte, schema strategicpatch.LookupPatchMeta) (*Patch, error) {
 	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, schema, true)
 	return create(patch, schema), err
 }
@@ -307,3 +307,36 @@ func TestPatching(t *testing.T) {
 		})
 	}
 }
+
+func TestStrategic(t *testing.T) {
+	tests := []struct {
+		name   string
+		obj    string
+		patch  string
+		expect string
+	}{{
+		name:   "registered type merges lists by key",
+		obj:    `{"apiVersion":"v1","kind":"Pod","spec":{"containers":[{"name":"a","image":"x"},{"name":"b","image":"y"}]}}`,
+		patch:  `{"spec":{"containers":[{"name":"b","image":"z"}]}}`,
+		expect: `{"apiVersion":"v1","kind":"Pod","spec":{"containers":[{"image":"x","name":"a"},{"image":"z","name":"b"}]}}`,
+	}, {
+		name:   "unregistered type replaces lists",
+		obj:    `{"apiVersion":"example.com/v1","kind":"Widget","spec":{"items":[{"name":"a"},{"name":"b"}]}}`,
+		patch:  `{"spec":{"items":[{"name":"b"}]}}`,
+		expect: `{"apiVersion":"example.com/v1","kind":"Widget","spec":{"items":[{"name":"b"}]}}`,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if err := u.UnmarshalJSON([]byte(test.obj)); err != nil {
+				t.Fatal(err)
+			}
+			if err := Strategic(u, []byte(test.patch)); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := u.MarshalJSON(); !bytes.Equal(bytes.TrimSpace(got), []byte(test.expect)) {
+				t.Errorf("\n     got %s\nexpected %s", got, test.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package refs
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/manifestival/manifestival/internal/workloads"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Renamer returns the new name of the resource of the given kind and
+// namespace, if it has been renamed. For references from
+// cluster-scoped resources lacking an explicit namespace, namespace
+// is empty.
+type Renamer func(kind, namespace, name string) (string, bool)
+
+// Update rewrites the names of any resources referenced by u that
+// have been renamed: ConfigMaps, Secrets, ServiceAccounts and
+// PersistentVolumeClaims used by pod specs, Roles and subjects of
+// bindings, Services of webhooks, APIServices, CRD conversions,
+// StatefulSets and Ingresses, and the targets of autoscalers.
+func Update(u *unstructured.Unstructured, rename Renamer) {
+	ns := u.GetNamespace()
+	if spec, ok := workloads.PodSpec(u); ok {
+		updatePodSpec(spec, ns, rename)
+	}
+	obj := u.Object
+	switch u.GetKind() {
+	case "StatefulSet":
+		updateField(obj, "Service", ns, rename, "spec", "serviceName")
+	case "ServiceAccount":
+		updateList(obj, "secrets", func(m map[string]interface{}) {
+			updateField(m, "Secret", ns, rename, "name")
+		})
+		updateList(obj, "imagePullSecrets", func(m map[string]interface{}) {
+			updateField(m, "Secret", ns, rename, "name")
+		})
+	case "RoleBinding", "ClusterRoleBinding":
+		if kind, _, _ := unstructured.NestedString(obj, "roleRef", "kind"); kind != "" {
+			roleNS := ns
+			if kind == "ClusterRole" {
+				roleNS = ""
+			}
+			updateField(obj, kind, roleNS, rename, "roleRef", "name")
+		}
+		updateList(obj, "subjects", func(m map[string]interface{}) {
+			if m["kind"] == "ServiceAccount" {
+				updateField(m, "ServiceAccount", namespaceOf(m, ns), rename, "name")
+			}
+		})
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		updateList(obj, "webhooks", func(m map[string]interface{}) {
+			updateService(m, rename, "clientConfig", "service")
+		})
+	case "APIService":
+		updateService(obj, rename, "spec", "service")
+	case "CustomResourceDefinition":
+		updateService(obj, rename, "spec", "conversion", "webhook", "clientConfig", "service")
+		updateService(obj, rename, "spec", "conversion", "webhookClientConfig", "service")
+	case "Ingress":
+		updateField(obj, "Service", ns, rename, "spec", "defaultBackend", "service", "name")
+		updateField(obj, "Service", ns, rename, "spec", "backend", "serviceName")
+		updateList(obj, "spec.tls", func(m map[string]interface{}) {
+			updateField(m, "Secret", ns, rename, "secretName")
+		})
+		updateList(obj, "spec.rules", func(rule map[string]interface{}) {
+			updateList(rule, "http.paths", func(m map[string]interface{}) {
+				updateField(m, "Service", ns, rename, "backend", "service", "name")
+				updateField(m, "Service", ns, rename, "backend", "serviceName")
+			})
+		})
+	case "HorizontalPodAutoscaler":
+		if kind, _, _ := unstructured.NestedString(obj, "spec", "scaleTargetRef", "kind"); kind != "" {
+			updateField(obj, kind, ns, rename, "spec", "scaleTargetRef", "name")
+		}
+	}
+}
+
+// updatePodSpec rewrites the references within a pod spec
+func updatePodSpec(spec map[string]interface{}, ns string, rename Renamer) {
+	updateField(spec, "ServiceAccount", ns, rename, "serviceAccountName")
+	updateField(spec, "ServiceAccount", ns, rename, "serviceAccount")
+	updateList(spec, "imagePullSecrets", func(m map[string]interface{}) {
+		updateField(m, "Secret", ns, rename, "name")
+	})
+	updateList(spec, "volumes", func(m map[string]interface{}) {
+		updateField(m, "ConfigMap", ns, rename, "configMap", "name")
+		updateField(m, "Secret", ns, rename, "secret", "secretName")
+		updateField(m, "PersistentVolumeClaim", ns, rename, "persistentVolumeClaim", "claimName")
+		updateList(m, "projected.sources", func(src map[string]interface{}) {
+			updateField(src, "ConfigMap", ns, rename, "configMap", "name")
+			updateField(src, "Secret", ns, rename, "secret", "name")
+		})
+	})
+	for _, c := range workloads.Containers(spec) {
+		updateList(c, "envFrom", func(m map[string]interface{}) {
+			updateField(m, "ConfigMap", ns, rename, "configMapRef", "name")
+			updateField(m, "Secret", ns, rename, "secretRef", "name")
+		})
+		updateList(c, "env", func(m map[string]interface{}) {
+			updateField(m, "ConfigMap", ns, rename, "valueFrom", "configMapKeyRef", "name")
+			updateField(m, "Secret", ns, rename, "valueFrom", "secretKeyRef", "name")
+		})
+	}
+}
+
+// updateService rewrites the name of a service reference, which
+// typically includes its namespace
+func updateService(obj map[string]interface{}, rename Renamer, fields ...string) {
+	svc, found, _ := unstructured.NestedMap(obj, fields...)
+	if !found {
+		return
+	}
+	ns, _ := svc["namespace"].(string)
+	updateField(obj, "Service", ns, rename, append(fields, "name")...)
+}
+
+// updateField rewrites the name at fields, if present
+func updateField(obj map[string]interface{}, kind, ns string, rename Renamer, fields ...string) {
+	name, found, _ := unstructured.NestedString(obj, fields...)
+	if !found || name == "" {
+		return
+	}
+	if newName, ok := rename(kind, ns, name); ok {
+		unstructured.SetNestedField(obj, newName, fields...)
+	}
+}
+
+// updateList calls fn for each map in the list at the dot-delimited
+// path, which may traverse only maps
+func updateList(obj map[string]interface{}, path string, fn func(map[string]interface{})) {
+	list, found, _ := unstructured.NestedFieldNoCopy(obj, strings.Split(path, ".")...)
+	if !found {
+		return
+	}
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			fn(m)
+		}
+	}
+}
+
+func namespaceOf(m map[string]interface{}, def string) string {
+	if ns, ok := m["namespace"].(string); ok && ns != "" {
+		return ns
+	}
+	return def
+}
+
+// Renames records the resources that have been renamed
+type Renames map[string]string
+
+// Add records the new name of a resource
+func (r Renames) Add(kind, namespace, oldName, newName string) {
+	r[key(kind, namespace, oldName)] = newName
+}
+
+// Rename is a Renamer for the recorded resources. A resource recorded
+// without a namespace matches one of the same kind and name in any
+// namespace, e.g. after a namespace is injected, and an empty
+// namespace matches a recorded resource in any namespace, the first
+// of them in order of namespace if there are several.
+func (r Renames) Rename(kind, namespace, name string) (string, bool) {
+	if newName, ok := r[key(kind, namespace, name)]; ok {
+		return newName, true
+	}
+	if newName, ok := r[key(kind, "", name)]; ok {
+		return newName, true
+	}
+	if namespace == "" {
+		matches := []string{}
+		for k := range r {
+			if parts := strings.SplitN(k, "|", 3); parts[0] == kind && parts[2] == name {
+				matches = append(matches, k)
+			}
+		}
+		if len(matches) > 0 {
+			sort.Strings(matches)
+			return r[matches[0]], true
+		}
+	}
+	return name, false
+}
+
+func key(kind, namespace, name string) string {
+	return kind + "|" + namespace + "|" + name
+}
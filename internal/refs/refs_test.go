@@ -0,0 +1,128 @@
+package refs_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	. "github.com/manifestival/manifestival/internal/refs"
+)
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		field  []interface{}
+		expect string
+	}{{
+		name: "cronjob configmap volume",
+		input: `
+kind: CronJob
+metadata: {namespace: ns}
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          volumes:
+          - configMap: {name: config}`,
+		field:  []interface{}{"spec", "jobTemplate", "spec", "template", "spec", "volumes", 0, "configMap", "name"},
+		expect: "new-config",
+	}, {
+		name: "env secret ref",
+		input: `
+kind: Pod
+metadata: {namespace: ns}
+spec:
+  initContainers:
+  - env:
+    - valueFrom:
+        secretKeyRef: {name: secret}`,
+		field:  []interface{}{"spec", "initContainers", 0, "env", 0, "valueFrom", "secretKeyRef", "name"},
+		expect: "new-secret",
+	}, {
+		name: "role binding subject in other namespace",
+		input: `
+kind: RoleBinding
+metadata: {namespace: ns}
+roleRef: {kind: Role, name: role}
+subjects:
+- {kind: ServiceAccount, name: sa, namespace: other}`,
+		field:  []interface{}{"subjects", 0, "name"},
+		expect: "sa",
+	}, {
+		name: "role binding role",
+		input: `
+kind: RoleBinding
+metadata: {namespace: ns}
+roleRef: {kind: Role, name: role}`,
+		field:  []interface{}{"roleRef", "name"},
+		expect: "new-role",
+	}, {
+		name: "webhook service without namespace",
+		input: `
+kind: ValidatingWebhookConfiguration
+webhooks:
+- clientConfig:
+    service: {name: svc}`,
+		field:  []interface{}{"webhooks", 0, "clientConfig", "service", "name"},
+		expect: "new-svc",
+	}}
+	renames := Renames{}
+	renames.Add("ConfigMap", "ns", "config", "new-config")
+	renames.Add("Secret", "ns", "secret", "new-secret")
+	renames.Add("ServiceAccount", "ns", "sa", "new-sa")
+	renames.Add("Role", "ns", "role", "new-role")
+	renames.Add("Service", "ns", "svc", "new-svc")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(test.input), &u.Object); err != nil {
+				t.Fatal(err)
+			}
+			Update(u, renames.Rename)
+			var v interface{} = u.Object
+			for _, f := range test.field {
+				switch k := f.(type) {
+				case string:
+					v = v.(map[string]interface{})[k]
+				case int:
+					v = v.([]interface{})[k]
+				}
+			}
+			if v != test.expect {
+				t.Errorf("Update() = %v, want %q", v, test.expect)
+			}
+		})
+	}
+}
+
+func TestRenames(t *testing.T) {
+	renames := Renames{}
+	renames.Add("ConfigMap", "b", "config", "b-config")
+	renames.Add("ConfigMap", "a", "config", "a-config")
+	renames.Add("ConfigMap", "c", "config", "c-config")
+	renames.Add("Secret", "", "secret", "new-secret")
+	tests := []struct {
+		kind, namespace, name string
+		want                  string
+		ok                    bool
+	}{
+		{"ConfigMap", "b", "config", "b-config", true},
+		{"ConfigMap", "d", "config", "config", false},
+		{"ConfigMap", "", "config", "a-config", true},
+		{"Secret", "", "secret", "new-secret", true},
+		{"Secret", "any", "secret", "new-secret", true},
+		{"Secret", "any", "other", "other", false},
+	}
+	for _, test := range tests {
+		// Repeated, since map iteration order varies
+		for i := 0; i < 10; i++ {
+			got, ok := renames.Rename(test.kind, test.namespace, test.name)
+			if got != test.want || ok != test.ok {
+				t.Fatalf("Rename(%s, %q, %s) = %q, %v, want %q, %v", test.kind, test.namespace, test.name, got, ok, test.want, test.ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// archiveExtensions are the file extensions recognized as archives
+var archiveExtensions = []string{".tar", ".tar.gz", ".tgz", ".zip"}
+
+// manifestExtensions are the extensions of files read from archives
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// IsArchive returns true if name has an archive file extension
+func IsArchive(name string) bool {
+	return hasExtension(name, archiveExtensions)
+}
+
+// IsManifest returns true if name has a YAML or JSON file extension
+func IsManifest(name string) bool {
+	return hasExtension(name, manifestExtensions)
+}
+
+// ReadArchive returns the contents of the YAML and JSON files within a
+// tar, gzipped tar or zip archive, ordered by path. The format is
+// detected from the content itself. If any patterns are given, only
+// those files whose paths match one of them, or are beneath a
+// directory matching one of them, are returned.
+func ReadArchive(data []byte, patterns ...string) ([][]byte, error) {
+	files, err := Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if IsManifest(name) && matches(name, patterns) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := make([][]byte, len(names))
+	for i, name := range names {
+		result[i] = files[name]
+	}
+	return result, nil
+}
+
+// Unpack returns the regular files within a tar, gzipped tar or zip
+// archive, keyed by their cleaned, relative paths
+func Unpack(data []byte) (map[string][]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return readZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return readTar(gz)
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		return readTar(bytes.NewReader(data))
+	}
+	return nil, fmt.Errorf("unrecognized archive format")
+}
+
+// readTar returns the regular files in a tarball, keyed by path
+func readTar(r io.Reader) (map[string][]byte, error) {
+	result := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if result[cleanPath(hdr.Name)], err = io.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readZip returns the regular files in a zip archive, keyed by path
+func readZip(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	result := map[string][]byte{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		result[cleanPath(f.Name)] = content
+	}
+	return result, nil
+}
+
+// matches returns true if there are no patterns, or name or any of
+// its parent directories match one of them
+func matches(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = cleanPath(pattern)
+		for p := name; p != "." && p != "/"; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cleanPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func hasExtension(name string, extensions []string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
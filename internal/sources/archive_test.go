@@ -0,0 +1,84 @@
+package sources_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/manifestival/manifestival/internal/sources"
+)
+
+func TestReadArchive(t *testing.T) {
+	tests := []struct {
+		name      string
+		archive   string
+		patterns  []string
+		want      []string
+		wantError bool
+	}{{
+		name:    "tarball",
+		archive: "testdata/tree.tar.gz",
+		want:    []string{"foo", "bar", "baz", "a", "b"},
+	}, {
+		name:    "zip",
+		archive: "testdata/tree.zip",
+		want:    []string{"foo", "bar", "baz", "a", "b"},
+	}, {
+		name:     "directory pattern",
+		archive:  "testdata/tree.tar",
+		patterns: []string{"dir"},
+		want:     []string{"foo", "bar", "baz"},
+	}, {
+		name:     "glob patterns",
+		archive:  "testdata/tree.zip",
+		patterns: []string{"*/a.yaml", "file.*"},
+		want:     []string{"foo", "a", "b"},
+	}, {
+		name:      "not an archive",
+		archive:   "testdata/tree/file.yaml",
+		wantError: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := os.ReadFile(test.archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			docs, err := ReadArchive(data, test.patterns...)
+			if test.wantError {
+				if err == nil {
+					t.Error("Expected an error from ReadArchive()")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := DecodeAll(docs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(actual) != len(test.want) {
+				t.Fatalf("ReadArchive() = %v, want %v", actual, test.want)
+			}
+			for i, spec := range actual {
+				if spec.GetName() != test.want[i] {
+					t.Errorf("ReadArchive() = %v, want %v", actual, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestArchiveURL(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+	actual, err := Parse(server.URL+"/tree.tar.gz?version=1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 5 {
+		t.Errorf("Expected 5 resources, got %v", actual)
+	}
+}
@@ -0,0 +1,144 @@
+package sources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache stores the content of fetched URLs in Dir. Content fetched
+// within TTL is returned without a request; otherwise it's
+// revalidated using its ETag or Last-Modified header. If the server
+// can't be reached, or responds with a 5xx status, any cached content
+// is returned regardless of its age, though not once the request's
+// context is canceled or times out. Caching is best-effort:
+// content that can't be written to Dir is still returned.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// cacheEntry is the metadata stored alongside cached content
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+// get returns the content of url, either cached or retrieved by do
+func (c *Cache) get(url string, do func(string, http.Header) (*response, error)) ([]byte, error) {
+	key := c.key(url)
+	entry, data := c.load(key, url)
+	if entry != nil && time.Since(entry.Fetched) < c.TTL {
+		return data, nil
+	}
+	header := http.Header{}
+	if entry != nil {
+		if entry.ETag != "" {
+			header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	resp, err := do(url, header)
+	switch {
+	case err != nil:
+		if entry != nil && isOffline(err) {
+			return data, nil
+		}
+		return nil, err
+	case entry != nil && resp.code == http.StatusNotModified:
+		entry.Fetched = time.Now()
+		c.store(key, entry, nil)
+		return data, nil
+	case entry != nil && resp.code >= 500:
+		return data, nil
+	case !resp.ok():
+		return nil, resp.err()
+	}
+	entry = &cacheEntry{
+		URL:          url,
+		ETag:         resp.header.Get("ETag"),
+		LastModified: resp.header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}
+	c.store(key, entry, resp.body)
+	return resp.body, nil
+}
+
+// key returns the path, less its extension, of the files caching url
+func (c *Cache) key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+// load returns the cached entry and content for url, or nil if
+// either is missing or unreadable
+func (c *Cache) load(key, url string) (*cacheEntry, []byte) {
+	meta, err := os.ReadFile(key + ".json")
+	if err != nil {
+		return nil, nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(meta, entry); err != nil || entry.URL != url {
+		return nil, nil
+	}
+	data, err := os.ReadFile(key + ".data")
+	if err != nil {
+		return nil, nil
+	}
+	return entry, data
+}
+
+// store writes the entry and, unless nil, the content for a key. It's
+// best-effort: on failure, the content is fetched again next time.
+func (c *Cache) store(key string, entry *cacheEntry, data []byte) {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return
+	}
+	if data != nil {
+		if err := writeFile(key+".data", data); err != nil {
+			return
+		}
+	}
+	if meta, err := json.Marshal(entry); err == nil {
+		writeFile(key+".json", meta)
+	}
+}
+
+// writeFile atomically replaces the file at name
+func writeFile(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// isOffline returns true if err arose from failing to reach a server
+// rather than, e.g., an oversized response or the request's context
+// being canceled or exceeding its deadline
+func isOffline(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
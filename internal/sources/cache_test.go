@@ -0,0 +1,94 @@
+package sources_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/manifestival/manifestival/internal/sources"
+)
+
+func TestCache(t *testing.T) {
+	var requests, status int
+	content := "v1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		etag := `"` + content + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	url := server.URL + "/manifest.yaml"
+
+	fetch := func(f *Fetcher, want string) {
+		t.Helper()
+		data, err := f.Fetch(url)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if string(data) != want {
+			t.Errorf("Fetch() = %q, want %q", data, want)
+		}
+	}
+	dir := t.TempDir()
+	revalidate := &Fetcher{Cache: &Cache{Dir: dir}}
+	fresh := &Fetcher{Cache: &Cache{Dir: dir, TTL: time.Hour}}
+
+	unwritable := filepath.Join(dir, "file")
+	if err := os.WriteFile(unwritable, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	fetch(&Fetcher{Cache: &Cache{Dir: unwritable}}, "v1")
+	requests = 0
+
+	fetch(revalidate, "v1")
+	fetch(fresh, "v1")
+	if requests != 1 {
+		t.Errorf("Expected content within TTL to be served from the cache, got %d requests", requests)
+	}
+	fetch(revalidate, "v1")
+	if requests != 2 {
+		t.Errorf("Expected content to be revalidated, got %d requests", requests)
+	}
+
+	content = "v2"
+	fetch(fresh, "v1")
+	fetch(revalidate, "v2")
+	fetch(fresh, "v2")
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+
+	status = http.StatusServiceUnavailable
+	fetch(revalidate, "v2")
+	status = http.StatusNotFound
+	if _, err := revalidate.Fetch(url); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Error("Expected a 404 error, got", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (&Fetcher{Context: canceled, Cache: &Cache{Dir: dir}}).Fetch(url); !errors.Is(err, context.Canceled) {
+		t.Error("Expected a canceled request to fail, got", err)
+	}
+
+	server.Close()
+	fetch(revalidate, "v2")
+	if _, err := (&Fetcher{Cache: &Cache{Dir: t.TempDir()}}).Fetch(url); err == nil {
+		t.Error("Expected an error fetching an uncached URL offline")
+	}
+}
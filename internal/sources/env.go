@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExpandEnv replaces ${VAR} and ${VAR:-default} references in s with
+// the values returned by lookup. The default is used when VAR is
+// either unset or empty. A reference preceded by an extra '$', e.g.
+// $${VAR}, is an escape yielding the literal ${VAR}. Bare $VAR
+// references and malformed ones are left alone. When strict, an error
+// naming every unset variable lacking a default is returned.
+func ExpandEnv(s string, lookup func(string) (string, bool), strict bool) (string, error) {
+	var b strings.Builder
+	missing := map[string]bool{}
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			// escaped: drop one '$' and keep the reference as is
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			break
+		}
+		name, def, hasDefault := strings.Cut(s[i+2:i+j], ":-")
+		if !isEnvName(name) {
+			b.WriteString(s[:i+2])
+			s = s[i+2:]
+			continue
+		}
+		b.WriteString(s[:i])
+		value, ok := lookup(name)
+		switch {
+		case hasDefault && value == "":
+			value = def
+		case !ok && strict:
+			missing[name] = true
+		}
+		b.WriteString(value)
+		s = s[i+j+1:]
+	}
+	b.WriteString(s)
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("undefined environment variables: %s", strings.Join(names, ", "))
+	}
+	return b.String(), nil
+}
+
+// isEnvName returns true for valid environment variable names
+func isEnvName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && '0' <= c && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package sources_test
+
+import (
+	"testing"
+
+	. "github.com/manifestival/manifestival/internal/sources"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{
+		"FOO":   "foo",
+		"EMPTY": "",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		name      string
+		input     string
+		strict    bool
+		want      string
+		wantError bool
+	}{{
+		name:  "no references",
+		input: "name: foo",
+		want:  "name: foo",
+	}, {
+		name:  "defined",
+		input: "name: ${FOO}-${FOO}",
+		want:  "name: foo-foo",
+	}, {
+		name:  "undefined",
+		input: "name: ${BAR}",
+		want:  "name: ",
+	}, {
+		name:      "undefined, strict",
+		input:     "name: ${BAR}",
+		strict:    true,
+		wantError: true,
+	}, {
+		name:   "undefined with default, strict",
+		input:  "name: ${BAR:-bar}",
+		strict: true,
+		want:   "name: bar",
+	}, {
+		name:   "empty, strict",
+		input:  "name: ${EMPTY}",
+		strict: true,
+		want:   "name: ",
+	}, {
+		name:  "empty with default",
+		input: "name: ${EMPTY:-bar}",
+		want:  "name: bar",
+	}, {
+		name:  "defined with default",
+		input: "name: ${FOO:-bar}",
+		want:  "name: foo",
+	}, {
+		name:   "escaped",
+		input:  "cmd: echo $${BAR} ${FOO}",
+		strict: true,
+		want:   "cmd: echo ${BAR} foo",
+	}, {
+		name:   "bare and malformed references",
+		input:  "cmd: $FOO ${1X} ${FOO",
+		strict: true,
+		want:   "cmd: $FOO ${1X} ${FOO",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ExpandEnv(test.input, lookup, test.strict)
+			if test.wantError {
+				if err == nil {
+					t.Errorf("Expected an error from ExpandEnv(), got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExpandEnv() = %v, wanted no error", err)
+			}
+			if actual != test.want {
+				t.Errorf("ExpandEnv() = %q, want %q", actual, test.want)
+			}
+		})
+	}
+}
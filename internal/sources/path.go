@@ -1,8 +1,9 @@
 package sources
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
 	"path"
@@ -13,21 +14,48 @@ import (
 
 // Parse parses YAML files into Unstructured objects.
 //
-// It supports 5 cases today:
+// It supports 6 cases today:
 //  1. pathname = path to a file --> parses that file.
 //  2. pathname = path to a directory, recursive = false --> parses all files in
 //     that directory.
 //  3. pathname = path to a directory, recursive = true --> parses all files in
 //     that directory and it's descendants
 //  4. pathname = url --> fetches the contents of that URL and parses them as YAML.
-//  5. pathname = combination of all previous cases, the string can contain
+//  5. pathname = path to, or url of, a .tar, .tar.gz, .tgz or .zip archive -->
+//     parses the YAML and JSON files within it.
+//  6. pathname = combination of all previous cases, the string can contain
 //     multiple records (file, directory or url) separated by comma
 func Parse(pathname string, recursive bool) ([]unstructured.Unstructured, error) {
+	docs, err := Read(pathname, recursive, nil)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeAll(docs)
+}
 
+// Read returns the unparsed contents of each file or URL referenced
+// by pathname, which supports the same cases as Parse. URLs are
+// fetched by f or, if nil, a zero Fetcher.
+func Read(pathname string, recursive bool, f *Fetcher) ([][]byte, error) {
+	if f == nil {
+		f = &Fetcher{}
+	}
 	pathnames := strings.Split(pathname, ",")
-	aggregated := []unstructured.Unstructured{}
+	if f.SHA256 != "" {
+		urls := 0
+		for _, pth := range pathnames {
+			if isURL(pth) {
+				urls++
+			}
+		}
+		if urls > 1 {
+			return nil, fmt.Errorf("a SHA256 digest can't verify %d URLs; use Checksums or a #sha256= fragment for each", urls)
+		}
+	}
+	sums := checksumFiles{}
+	aggregated := [][]byte{}
 	for _, pth := range pathnames {
-		els, err := read(pth, recursive)
+		els, err := read(pth, recursive, f, sums)
 		if err != nil {
 			return nil, err
 		}
@@ -37,11 +65,24 @@ func Parse(pathname string, recursive bool) ([]unstructured.Unstructured, error)
 	return aggregated, nil
 }
 
+// DecodeAll parses each document's contents as YAML.
+func DecodeAll(docs [][]byte) ([]unstructured.Unstructured, error) {
+	aggregated := []unstructured.Unstructured{}
+	for _, doc := range docs {
+		els, err := Decode(bytes.NewReader(doc))
+		if err != nil {
+			return nil, err
+		}
+		aggregated = append(aggregated, els...)
+	}
+	return aggregated, nil
+}
+
 // read cotains a logic to distinguish the type of record in pathname
 // (file, directory or url) and calls the appropriate function
-func read(pathname string, recursive bool) ([]unstructured.Unstructured, error) {
+func read(pathname string, recursive bool, f *Fetcher, sums checksumFiles) ([][]byte, error) {
 	if isURL(pathname) {
-		return readURL(pathname)
+		return readURL(pathname, f, sums)
 	}
 
 	info, err := os.Stat(pathname)
@@ -55,30 +96,32 @@ func read(pathname string, recursive bool) ([]unstructured.Unstructured, error)
 	return readFile(pathname)
 }
 
-// readFile parses a single file.
-func readFile(pathname string) ([]unstructured.Unstructured, error) {
-	file, err := os.Open(pathname)
+// readFile reads a single file, or the manifests within it if it's
+// an archive.
+func readFile(pathname string) ([][]byte, error) {
+	data, err := ioutil.ReadFile(pathname)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	return Decode(file)
+	if IsArchive(pathname) {
+		return ReadArchive(data)
+	}
+	return [][]byte{data}, nil
 }
 
-// readDir parses all files in a single directory and it's descendant directories
+// readDir reads all files in a single directory and it's descendant directories
 // if the recursive flag is set to true.
-func readDir(pathname string, recursive bool) ([]unstructured.Unstructured, error) {
+func readDir(pathname string, recursive bool) ([][]byte, error) {
 	list, err := ioutil.ReadDir(pathname)
 	if err != nil {
 		return nil, err
 	}
 
-	aggregated := []unstructured.Unstructured{}
+	aggregated := [][]byte{}
 	for _, f := range list {
 		name := path.Join(pathname, f.Name())
 		pathDirOrFile, err := os.Stat(name)
-		var els []unstructured.Unstructured
+		var els [][]byte
 
 		if os.IsNotExist(err) || os.IsPermission(err) {
 			return aggregated, err
@@ -99,15 +142,21 @@ func readDir(pathname string, recursive bool) ([]unstructured.Unstructured, erro
 	return aggregated, nil
 }
 
-// readURL fetches a URL and parses its contents as YAML.
-func readURL(url string) ([]unstructured.Unstructured, error) {
-	resp, err := http.Get(url)
+// ReadURL fetches the contents of a URL, or the manifests within it
+// if it's an archive.
+func ReadURL(pathname string, f *Fetcher) ([][]byte, error) {
+	return readURL(pathname, f, checksumFiles{})
+}
+
+func readURL(pathname string, f *Fetcher, sums checksumFiles) ([][]byte, error) {
+	data, err := f.fetch(pathname, sums)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	return Decode(resp.Body)
+	if u, err := url.Parse(pathname); err == nil && IsArchive(u.Path) {
+		return ReadArchive(data)
+	}
+	return [][]byte{data}, nil
 }
 
 // isURL checks whether or not the given path parses as a URL.
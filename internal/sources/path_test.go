@@ -77,6 +77,18 @@ func TestParsing(t *testing.T) {
 		path:      filepath.FromSlash("testdata/tree/file.yaml") + "," + filepath.FromSlash("testdata/tree/dir"),
 		recursive: true,
 		want:      []string{"a", "b", "foo", "bar", "baz"},
+	}, {
+		name: "tarball",
+		path: filepath.FromSlash("testdata/tree.tar.gz"),
+		want: []string{"foo", "bar", "baz", "a", "b"},
+	}, {
+		name: "uncompressed tarball",
+		path: filepath.FromSlash("testdata/tree.tar"),
+		want: []string{"foo", "bar", "baz", "a", "b"},
+	}, {
+		name: "zip and file",
+		path: filepath.FromSlash("testdata/tree.zip") + "," + filepath.FromSlash("testdata/tree/dir/a.yaml"),
+		want: []string{"foo", "bar", "baz", "a", "b", "foo"},
 	}, {
 		name:      "empty -> invalid input",
 		path:      "",
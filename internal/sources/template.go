@@ -0,0 +1,245 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Render executes data as a text/template with the given values as
+// its root object. Only the functions in TemplateFuncs are available,
+// none of which have side effects or access the environment.
+func Render(name string, data []byte, values interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=zero").
+		Funcs(TemplateFuncs()).
+		Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := EmptyMissing(tmpl).Execute(&buf, values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// emptyMissingFunc is appended to every action that prints a value
+const emptyMissingFunc = "_emptyMissing"
+
+// EmptyMissing rewrites the parsed actions of t, and of the templates
+// associated with it, so that missing values are printed as empty
+// rather than "<no value>". Text outside of the actions is untouched.
+func EmptyMissing(t *template.Template) *template.Template {
+	t.Funcs(template.FuncMap{emptyMissingFunc: emptyMissing})
+	for _, x := range t.Templates() {
+		if x.Tree != nil {
+			appendEmptyMissing(x.Tree, x.Tree.Root)
+		}
+	}
+	return t
+}
+
+func emptyMissing(v interface{}) interface{} {
+	if v == nil {
+		return ""
+	}
+	return v
+}
+
+// appendEmptyMissing pipes the value of each printing action below
+// node to emptyMissing, unless it already is
+func appendEmptyMissing(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, x := range n.Nodes {
+			appendEmptyMissing(tree, x)
+		}
+	case *parse.IfNode:
+		appendEmptyMissing(tree, n.List)
+		appendEmptyMissing(tree, n.ElseList)
+	case *parse.RangeNode:
+		appendEmptyMissing(tree, n.List)
+		appendEmptyMissing(tree, n.ElseList)
+	case *parse.WithNode:
+		appendEmptyMissing(tree, n.List)
+		appendEmptyMissing(tree, n.ElseList)
+	case *parse.ActionNode:
+		pipe := n.Pipe
+		if len(pipe.Decl) > 0 || len(pipe.Cmds) == 0 {
+			return
+		}
+		last := pipe.Cmds[len(pipe.Cmds)-1]
+		if id, ok := last.Args[0].(*parse.IdentifierNode); ok && id.Ident == emptyMissingFunc {
+			return
+		}
+		id := parse.NewIdentifier(emptyMissingFunc).SetTree(tree).SetPos(pipe.Pos)
+		pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      pipe.Pos,
+			Args:     []parse.Node{id},
+		})
+	}
+}
+
+// TemplateFuncs returns the functions available to templates
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default":  defaultValue,
+		"empty":    empty,
+		"coalesce": coalesce,
+		"required": required,
+		"quote":    quote,
+		"squote":   squote,
+		"toYaml":   toYaml,
+		"fromYaml": fromYaml,
+		"toJson":   toJson,
+		"indent":   indent,
+		"nindent":  nindent,
+		"trim":     strings.TrimSpace,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"replace":  replace,
+		"contains": contains,
+		"list":     list,
+		"dict":     dict,
+	}
+}
+
+// defaultValue returns d if v is empty, e.g. `{{ .tag | default "latest" }}`
+func defaultValue(d interface{}, v ...interface{}) interface{} {
+	if len(v) == 0 || empty(v[0]) {
+		return d
+	}
+	return v[0]
+}
+
+// empty returns true for nil and zero values, and empty collections
+func empty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+// coalesce returns the first non-empty argument
+func coalesce(v ...interface{}) interface{} {
+	for _, x := range v {
+		if !empty(x) {
+			return x
+		}
+	}
+	return nil
+}
+
+// required fails the rendering with msg when v is empty
+func required(msg string, v interface{}) (interface{}, error) {
+	if empty(v) {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+func quote(v ...interface{}) string {
+	result := make([]string, 0, len(v))
+	for _, x := range v {
+		if x != nil {
+			result = append(result, fmt.Sprintf("%q", toString(x)))
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+func squote(v ...interface{}) string {
+	result := make([]string, 0, len(v))
+	for _, x := range v {
+		if x != nil {
+			result = append(result, "'"+toString(x)+"'")
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+// toYaml marshals v without a trailing newline, so that it may be
+// composed with indent/nindent
+func toYaml(v interface{}) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+func fromYaml(s string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(s), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func toJson(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// indent prefixes every line of s with n spaces
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// nindent is indent preceded by a newline
+func nindent(n int, s string) string {
+	return "\n" + indent(n, s)
+}
+
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+func contains(substr, s string) bool {
+	return strings.Contains(s, substr)
+}
+
+func list(v ...interface{}) []interface{} {
+	return v
+}
+
+// dict builds a map from alternating keys and values
+func dict(v ...interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for i := 0; i+1 < len(v); i += 2 {
+		result[toString(v[i])] = v[i+1]
+	}
+	return result
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case fmt.Stringer:
+		return s.String()
+	}
+	return fmt.Sprint(v)
+}
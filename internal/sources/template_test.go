@@ -0,0 +1,86 @@
+package sources_test
+
+import (
+	"testing"
+
+	. "github.com/manifestival/manifestival/internal/sources"
+)
+
+func TestRender(t *testing.T) {
+	values := map[string]interface{}{
+		"name":   "foo",
+		"labels": map[string]interface{}{"app": "foo"},
+		"port":   8080,
+	}
+	tests := []struct {
+		name      string
+		template  string
+		want      string
+		wantError bool
+	}{{
+		name:     "value",
+		template: "name: {{ .name }}",
+		want:     "name: foo",
+	}, {
+		name:     "missing value",
+		template: "name: {{ .missing }}",
+		want:     "name: ",
+	}, {
+		name:     "missing value in a block",
+		template: "{{ if .name }}{{ range $k, $v := .labels }}{{ $k }}: {{ $.missing }}{{ end }}{{ end }}",
+		want:     "app: ",
+	}, {
+		name:     "literal no value",
+		template: "# <no value>\nname: {{ .name }}",
+		want:     "# <no value>\nname: foo",
+	}, {
+		name:     "default",
+		template: "tag: {{ .tag | default \"latest\" }}",
+		want:     "tag: latest",
+	}, {
+		name:     "default not used",
+		template: "name: {{ .name | default \"bar\" }}",
+		want:     "name: foo",
+	}, {
+		name:     "quote",
+		template: "port: {{ .port | quote }}",
+		want:     "port: \"8080\"",
+	}, {
+		name:     "toYaml",
+		template: "labels:{{ .labels | toYaml | nindent 2 }}",
+		want:     "labels:\n  app: foo",
+	}, {
+		name:     "indent",
+		template: "{{ \"a\\nb\" | indent 2 }}",
+		want:     "  a\n  b",
+	}, {
+		name:      "required",
+		template:  "{{ required \"tag is required\" .tag }}",
+		wantError: true,
+	}, {
+		name:      "invalid template",
+		template:  "{{ .name ",
+		wantError: true,
+	}, {
+		name:      "unsafe function",
+		template:  "{{ env \"HOME\" }}",
+		wantError: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Render(test.name, []byte(test.template), values)
+			if test.wantError {
+				if err == nil {
+					t.Errorf("Expected an error from Render(), got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() = %v, wanted no error", err)
+			}
+			if string(actual) != test.want {
+				t.Errorf("Render() = %q, want %q", actual, test.want)
+			}
+		})
+	}
+}
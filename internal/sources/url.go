@@ -0,0 +1,216 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Fetcher retrieves the content of URLs. Its zero value uses
+// http.DefaultClient with no timeout or size limit.
+type Fetcher struct {
+	Client   *http.Client
+	Header   http.Header
+	Timeout  time.Duration
+	Context  context.Context
+	MaxBytes int64
+
+	// SHA256 is the expected hex digest of the fetched URL, so Read
+	// rejects it for a pathname with more than one URL
+	SHA256 string
+	// Checksums is the URL, absolute or relative to each fetched
+	// URL, of a file in the format of sha256sum's output listing the
+	// expected digest of each fetched file by name. Read fetches
+	// each Checksums file only once.
+	Checksums string
+	// Cache, if not nil, stores fetched content on disk
+	Cache *Cache
+}
+
+// checksumFiles are the parsed Checksums files, keyed by URL, of a
+// single Read
+type checksumFiles map[string]map[string]string
+
+// Fetch returns the body of a GET request for rawurl, failing unless
+// the response has a 2xx status. If rawurl has a fragment of the form
+// #sha256=<digest>, the body must have that digest, as well as any
+// required by the SHA256 and Checksums fields.
+func (f *Fetcher) Fetch(rawurl string) ([]byte, error) {
+	return f.fetch(rawurl, checksumFiles{})
+}
+
+func (f *Fetcher) fetch(rawurl string, sums checksumFiles) ([]byte, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	expected := []string{}
+	if strings.HasPrefix(u.Fragment, "sha256=") {
+		expected = append(expected, strings.TrimPrefix(u.Fragment, "sha256="))
+		u.Fragment = ""
+		rawurl = u.String()
+	}
+	if f.SHA256 != "" {
+		expected = append(expected, f.SHA256)
+	}
+	if f.Checksums != "" {
+		digest, err := f.checksum(u, sums)
+		if err != nil {
+			return nil, err
+		}
+		expected = append(expected, digest)
+	}
+	data, err := f.get(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) > 0 {
+		sum := sha256.Sum256(data)
+		actual := hex.EncodeToString(sum[:])
+		for _, digest := range expected {
+			if !strings.EqualFold(digest, actual) {
+				return nil, fmt.Errorf("%s: sha256 checksum mismatch: expected %s, got %s", rawurl, digest, actual)
+			}
+		}
+	}
+	return data, nil
+}
+
+// checksum returns the digest listed for the file at u in the
+// Checksums file, parsing it unless already in sums
+func (f *Fetcher) checksum(u *url.URL, sums checksumFiles) (string, error) {
+	ref, err := url.Parse(f.Checksums)
+	if err != nil {
+		return "", err
+	}
+	location := u.ResolveReference(ref).String()
+	digests, ok := sums[location]
+	if !ok {
+		data, err := f.get(location)
+		if err != nil {
+			return "", err
+		}
+		digests = parseChecksums(data)
+		sums[location] = digests
+	}
+	name := path.Base(u.Path)
+	if digest, ok := digests[name]; ok {
+		return digest, nil
+	}
+	return "", fmt.Errorf("%s: no checksum for %s", location, name)
+}
+
+// parseChecksums returns the digests, keyed by file name, in the
+// output of either `sha256sum` or the BSD-style `sha256 -r`/`shasum
+// --tag` formats
+func parseChecksums(data []byte) map[string]string {
+	result := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "SHA256 (") {
+			if i := strings.LastIndex(line, ") = "); i > 0 {
+				result[path.Base(line[8:i])] = line[i+4:]
+			}
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(fields[1]), "*")
+		result[path.Base(name)] = fields[0]
+	}
+	return result
+}
+
+// get returns the body of a GET request for url, from the Cache if
+// there is one
+func (f *Fetcher) get(url string) ([]byte, error) {
+	if f.Cache != nil {
+		return f.Cache.get(url, f.do)
+	}
+	resp, err := f.do(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.ok() {
+		return nil, resp.err()
+	}
+	return resp.body, nil
+}
+
+// response is the outcome of a GET request, whatever its status
+type response struct {
+	url    string
+	status string
+	code   int
+	header http.Header
+	body   []byte
+}
+
+func (r *response) ok() bool {
+	return r.code >= 200 && r.code <= 299
+}
+
+func (r *response) err() error {
+	return fmt.Errorf("GET %s: %s", r.url, r.status)
+}
+
+// do makes a GET request for url with any additional headers
+func (f *Fetcher) do(url string, header http.Header) (*response, error) {
+	ctx := f.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []http.Header{f.Header, header} {
+		for k, values := range h {
+			for _, v := range values {
+				req.Header.Add(k, v)
+			}
+		}
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &response{url: url, status: resp.Status, code: resp.StatusCode, header: resp.Header}
+	if !result.ok() {
+		return result, nil
+	}
+	var body io.Reader = resp.Body
+	if f.MaxBytes > 0 {
+		body = io.LimitReader(resp.Body, f.MaxBytes+1)
+	}
+	if result.body, err = io.ReadAll(body); err != nil {
+		return nil, err
+	}
+	if f.MaxBytes > 0 && int64(len(result.body)) > f.MaxBytes {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", url, f.MaxBytes)
+	}
+	return result, nil
+}
@@ -0,0 +1,227 @@
+package sources_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/manifestival/manifestival/internal/sources"
+)
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		case "/slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		w.Write([]byte("kind: A\n"))
+	}))
+	defer server.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		path      string
+		fetcher   Fetcher
+		wantError string
+	}{{
+		name: "default",
+		path: "/",
+	}, {
+		name:      "not found",
+		path:      "/missing",
+		wantError: "404 Not Found",
+	}, {
+		name:      "unauthorized",
+		path:      "/auth",
+		wantError: "401 Unauthorized",
+	}, {
+		name:    "authorized",
+		path:    "/auth",
+		fetcher: Fetcher{Header: http.Header{"Authorization": {"Bearer secret"}}},
+	}, {
+		name:    "within size limit",
+		path:    "/",
+		fetcher: Fetcher{MaxBytes: 8},
+	}, {
+		name:      "exceeds size limit",
+		path:      "/",
+		fetcher:   Fetcher{MaxBytes: 7},
+		wantError: "exceeds 7 bytes",
+	}, {
+		name:      "timeout",
+		path:      "/slow",
+		fetcher:   Fetcher{Timeout: 10 * time.Millisecond},
+		wantError: "deadline exceeded",
+	}, {
+		name:      "canceled",
+		path:      "/",
+		fetcher:   Fetcher{Context: canceled},
+		wantError: "canceled",
+	}, {
+		name:    "custom client",
+		path:    "/",
+		fetcher: Fetcher{Client: server.Client()},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := test.fetcher.Fetch(server.URL + test.path)
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("Fetch() = %v, wanted error containing %q", err, test.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if string(data) != "kind: A\n" {
+				t.Errorf("Fetch() = %q", data)
+			}
+		})
+	}
+}
+
+func TestFetchChecksum(t *testing.T) {
+	const content = "kind: A\n"
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+	wrong := strings.Repeat("0", 64)
+	sums := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release/SHA256SUMS":
+			sums++
+			fmt.Fprintf(w, "%s  other.yaml\n%s *manifest.yaml\n", wrong, digest)
+		case "/release/BSD":
+			fmt.Fprintf(w, "SHA256 (dist/manifest.yaml) = %s\n", digest)
+		case "/release/WRONG":
+			fmt.Fprintf(w, "%s  manifest.yaml\n", wrong)
+		case "/release/manifest.yaml", "/release/other.yaml":
+			w.Write([]byte(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	manifest := server.URL + "/release/manifest.yaml"
+
+	tests := []struct {
+		name      string
+		url       string
+		fetcher   Fetcher
+		wantError string
+	}{{
+		name: "fragment",
+		url:  manifest + "#sha256=" + digest,
+	}, {
+		name: "uppercase fragment",
+		url:  manifest + "#sha256=" + strings.ToUpper(digest),
+	}, {
+		name:      "fragment mismatch",
+		url:       manifest + "#sha256=" + wrong,
+		wantError: "checksum mismatch",
+	}, {
+		name:    "option",
+		url:     manifest,
+		fetcher: Fetcher{SHA256: digest},
+	}, {
+		name:      "option mismatch",
+		url:       manifest + "#sha256=" + digest,
+		fetcher:   Fetcher{SHA256: wrong},
+		wantError: "checksum mismatch",
+	}, {
+		name:    "relative checksums file",
+		url:     manifest,
+		fetcher: Fetcher{Checksums: "SHA256SUMS"},
+	}, {
+		name:    "absolute checksums file",
+		url:     manifest,
+		fetcher: Fetcher{Checksums: server.URL + "/release/BSD"},
+	}, {
+		name:      "checksums file mismatch",
+		url:       manifest,
+		fetcher:   Fetcher{Checksums: "WRONG"},
+		wantError: "checksum mismatch",
+	}, {
+		name:      "checksums file mismatch for other file",
+		url:       server.URL + "/release/other.yaml",
+		fetcher:   Fetcher{Checksums: "SHA256SUMS"},
+		wantError: "checksum mismatch",
+	}, {
+		name:      "no entry in checksums file",
+		url:       server.URL + "/release/other.yaml",
+		fetcher:   Fetcher{Checksums: "BSD"},
+		wantError: "no checksum for other.yaml",
+	}, {
+		name:      "missing checksums file",
+		url:       manifest,
+		fetcher:   Fetcher{Checksums: "MISSING"},
+		wantError: "404 Not Found",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := test.fetcher.Fetch(test.url)
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("Fetch() = %v, wanted error containing %q", err, test.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if string(data) != content {
+				t.Errorf("Fetch() = %q", data)
+			}
+		})
+	}
+
+	// Each checksums file is fetched once
+	sums = 0
+	other := server.URL + "/release/other.yaml"
+	fetcher := &Fetcher{Checksums: "SHA256SUMS"}
+	_, err := Read(manifest+","+other, false, fetcher)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Read() = %v, wanted a checksum mismatch for other.yaml", err)
+	}
+	if sums != 1 {
+		t.Errorf("Expected the checksums file to be fetched once, got %d", sums)
+	}
+	// ...for each Read
+	if _, err := Read(manifest, false, fetcher); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	if sums != 2 {
+		t.Errorf("Expected the checksums file to be fetched again, got %d", sums)
+	}
+
+	// A single digest can't verify multiple URLs
+	if _, err := Read(manifest+","+manifest, false, &Fetcher{SHA256: digest}); err == nil {
+		t.Error("Expected an error verifying multiple URLs with one digest")
+	}
+	if _, err := Read(manifest+",testdata/tree", false, &Fetcher{SHA256: digest}); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Package workloads locates the pod specs of workloads: Pods, and the
+// pod templates of Deployments, StatefulSets, DaemonSets, ReplicaSets,
+// ReplicationControllers, Jobs and CronJobs.
+package workloads
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// TemplatePath returns the fields leading to the pod template of a
+// workload kind, or nil if the kind has none. Pods themselves have no
+// template, so their path is empty but non-nil.
+func TemplatePath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return []string{"spec", "template"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template"}
+	}
+	return nil
+}
+
+// IsWorkload returns true for kinds that include a pod spec
+func IsWorkload(kind string) bool {
+	return TemplatePath(kind) != nil
+}
+
+// PodSpec returns the pod spec of a workload, which may be modified
+// in place, or false if there is none
+func PodSpec(u *unstructured.Unstructured) (map[string]interface{}, bool) {
+	path := TemplatePath(u.GetKind())
+	if path == nil {
+		return nil, false
+	}
+	spec, found, err := unstructured.NestedFieldNoCopy(u.Object, append(path, "spec")...)
+	if !found || err != nil {
+		return nil, false
+	}
+	m, ok := spec.(map[string]interface{})
+	return m, ok
+}
+
+// ContainerFields are the pod spec fields containing containers
+var ContainerFields = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// Containers returns every container in a pod spec, in the order of
+// ContainerFields, each of which may be modified in place
+func Containers(podSpec map[string]interface{}) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, field := range ContainerFields {
+		list, _ := podSpec[field].([]interface{})
+		for _, c := range list {
+			if m, ok := c.(map[string]interface{}); ok {
+				result = append(result, m)
+			}
+		}
+	}
+	return result
+}
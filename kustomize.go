@@ -0,0 +1,293 @@
+package manifestival
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/manifestival/manifestival/internal/kustomize"
+	"github.com/manifestival/manifestival/internal/refs"
+	"github.com/manifestival/manifestival/internal/sources"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Kustomize is a Source that builds the kustomization in a local
+// directory, much like `kustomize build` would, though only a subset
+// of its fields are supported.
+type Kustomize string
+
+var _ Source = Kustomize("")
+
+func (k Kustomize) Parse() ([]unstructured.Unstructured, error) {
+	resources, err := kustomizeBuild(string(k))
+	if err != nil {
+		return nil, err
+	}
+	return kustomizeHash(resources)
+}
+
+// kustomizeBuild recursively builds a kustomization, deferring the
+// hashing of generated names to the top-level build
+func kustomizeBuild(dir string) ([]unstructured.Unstructured, error) {
+	kz, err := kustomize.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	resources := []unstructured.Unstructured{}
+	for _, r := range append(append([]string{}, kz.Bases...), kz.Resources...) {
+		els, err := kustomizeResource(dir, r)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, els...)
+	}
+	if resources, err = kustomizeGenerate(dir, kz, resources); err != nil {
+		return nil, err
+	}
+	if resources, err = kustomizePatch(dir, kz, resources); err != nil {
+		return nil, err
+	}
+	if resources, err = kustomizeWorkloads(kz, resources); err != nil {
+		return nil, err
+	}
+	if kz.Namespace != "" {
+		inject := Manifest{resources: resources}.InjectNamespace(kz.Namespace)
+		for i := range resources {
+			if err := inject(&resources[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if kz.NamePrefix != "" || kz.NameSuffix != "" {
+		rename := AffixNames(kz.NamePrefix, kz.NameSuffix, Manifest{resources: resources})
+		for i := range resources {
+			if err := rename(&resources[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i := range resources {
+		kustomize.AddLabels(&resources[i], kz.CommonLabels, true, true)
+		kustomize.AddAnnotations(&resources[i], kz.CommonAnnotations, true)
+		for _, l := range kz.Labels {
+			kustomize.AddLabels(&resources[i], l.Pairs, l.IncludeTemplates, l.IncludeSelectors)
+		}
+	}
+	return resources, nil
+}
+
+// kustomizeWorkloads overrides the images and replicas of workloads
+func kustomizeWorkloads(kz *kustomize.Kustomization, resources []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	images := make([]Image, len(kz.Images))
+	for i, img := range kz.Images {
+		if img.Name == "" {
+			return nil, fmt.Errorf("images entry requires a name")
+		}
+		images[i] = Image{Name: img.Name, NewName: img.NewName, NewTag: img.NewTag, Digest: img.Digest}
+	}
+	transform := InjectImages(images...)
+	for i := range resources {
+		u := &resources[i]
+		if err := transform(u); err != nil {
+			return nil, err
+		}
+		for _, r := range kz.Replicas {
+			switch u.GetKind() {
+			case "Deployment", "ReplicaSet", "ReplicationController", "StatefulSet":
+				if u.GetName() == r.Name {
+					if err := unstructured.SetNestedField(u.Object, r.Count, "spec", "replicas"); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return resources, nil
+}
+
+// kustomizeResource loads a file, URL or kustomization directory
+func kustomizeResource(dir, r string) ([]unstructured.Unstructured, error) {
+	if strings.Contains(r, "://") {
+		return sources.Parse(r, false)
+	}
+	if !filepath.IsAbs(r) {
+		r = filepath.Join(dir, r)
+	}
+	info, err := os.Stat(r)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return kustomizeBuild(r)
+	}
+	return sources.Parse(r, false)
+}
+
+// kustomizeGenerate adds, merges or replaces generated resources
+func kustomizeGenerate(dir string, kz *kustomize.Kustomization, resources []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	type generate func(kustomize.Generator) (*unstructured.Unstructured, error)
+	configMap := func(g kustomize.Generator) (*unstructured.Unstructured, error) {
+		return g.ConfigMap(dir, kz.GeneratorOptions)
+	}
+	secret := func(g kustomize.Generator) (*unstructured.Unstructured, error) {
+		return g.Secret(dir, kz.GeneratorOptions)
+	}
+	for _, x := range []struct {
+		generators []kustomize.Generator
+		fn         generate
+	}{{kz.ConfigMapGenerator, configMap}, {kz.SecretGenerator, secret}} {
+		for _, g := range x.generators {
+			u, err := x.fn(g)
+			if err != nil {
+				return nil, err
+			}
+			i := -1
+			for j := range resources {
+				if resources[j].GetKind() == u.GetKind() && resources[j].GetName() == u.GetName() &&
+					(g.Namespace == "" || resources[j].GetNamespace() == g.Namespace) {
+					i = j
+				}
+			}
+			switch {
+			case g.Behavior == "" || g.Behavior == "create":
+				if i >= 0 {
+					return nil, fmt.Errorf("%s %s already exists; use behavior merge or replace", u.GetKind(), u.GetName())
+				}
+				resources = append(resources, *u)
+			case i < 0:
+				return nil, fmt.Errorf("%s %s not found for behavior %s", u.GetKind(), u.GetName(), g.Behavior)
+			case g.Behavior == "replace":
+				u.SetNamespace(resources[i].GetNamespace())
+				resources[i] = *u
+			case g.Behavior == "merge":
+				base := &resources[i]
+				for _, field := range []string{"data", "binaryData"} {
+					if m, ok := u.Object[field].(map[string]interface{}); ok {
+						data, _, _ := unstructured.NestedMap(base.Object, field)
+						if data == nil {
+							data = map[string]interface{}{}
+						}
+						for k, v := range m {
+							data[k] = v
+						}
+						base.Object[field] = data
+					}
+				}
+				kustomize.AddLabels(base, u.GetLabels(), false, false)
+				kustomize.AddAnnotations(base, u.GetAnnotations(), false)
+			default:
+				return nil, fmt.Errorf("invalid generator behavior %q", g.Behavior)
+			}
+		}
+	}
+	return resources, nil
+}
+
+// kustomizePatch applies each type of patch in the kustomization
+func kustomizePatch(dir string, kz *kustomize.Kustomization, resources []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	var err error
+	for _, p := range kz.PatchesStrategicMerge {
+		data := []byte(p)
+		if _, statErr := os.Stat(filepath.Join(dir, p)); statErr == nil {
+			if data, err = os.ReadFile(filepath.Join(dir, p)); err != nil {
+				return nil, err
+			}
+		}
+		patches, err := sources.Decode(strings.NewReader(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, patch := range patches {
+			if resources, err = kustomizeApply(resources, nil, patch.Object); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, p := range append(append([]kustomize.Patch{}, kz.Patches...), kz.PatchesJson6902...) {
+		data, err := p.Content(dir)
+		if err != nil {
+			return nil, err
+		}
+		ops, patch, err := kustomize.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		if ops != nil {
+			if p.Target == nil {
+				return nil, fmt.Errorf("JSON 6902 patch requires a target")
+			}
+			for i := range resources {
+				if ok, err := p.Target.Matches(&resources[i]); err != nil {
+					return nil, err
+				} else if ok {
+					if err := kustomize.ApplyJSON(&resources[i], ops); err != nil {
+						return nil, err
+					}
+				}
+			}
+			continue
+		}
+		if resources, err = kustomizeApply(resources, p.Target, patch); err != nil {
+			return nil, err
+		}
+	}
+	return resources, nil
+}
+
+// kustomizeApply applies a strategic merge patch to the resources
+// matching target or, if nil, the resource the patch identifies
+func kustomizeApply(resources []unstructured.Unstructured, target *kustomize.Selector, patch map[string]interface{}) ([]unstructured.Unstructured, error) {
+	var err error
+	explicit := target != nil
+	if !explicit {
+		if target, err = kustomize.SelectorFor(patch); err != nil {
+			return nil, err
+		}
+	}
+	patch = kustomize.WithoutIdentity(patch)
+	result := []unstructured.Unstructured{}
+	matched := false
+	for i := range resources {
+		u := &resources[i]
+		ok, err := target.Matches(u)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = true
+			keep, err := kustomize.ApplyStrategic(u, runtime.DeepCopyJSON(patch))
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				continue
+			}
+		}
+		result = append(result, *u)
+	}
+	if !matched && !explicit {
+		return nil, fmt.Errorf("no target found for patch of %s %s", target.Kind, target.Name)
+	}
+	return result, nil
+}
+
+// kustomizeHash appends a hash of their content to the names of
+// generated resources and updates any references to them
+func kustomizeHash(resources []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	renames := refs.Renames{}
+	for i := range resources {
+		u := &resources[i]
+		name := u.GetName()
+		if renamed, err := appendHash(u); err != nil {
+			return nil, err
+		} else if renamed {
+			renames.Add(u.GetKind(), u.GetNamespace(), name, u.GetName())
+		}
+	}
+	for i := range resources {
+		refs.Update(&resources[i], renames.Rename)
+	}
+	return resources, nil
+}
@@ -0,0 +1,119 @@
+package manifestival_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestKustomizeBase(t *testing.T) {
+	m, err := ManifestFrom(Kustomize("testdata/kustomize/base"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Resources()) != 4 {
+		t.Fatalf("Expected 4 resources, got %d", len(m.Resources()))
+	}
+	cm := m.Filter(ByKind("ConfigMap")).Resources()[0]
+	if name := cm.GetName(); !strings.HasPrefix(name, "web-config-") || len(name) != len("web-config-")+10 {
+		t.Errorf("Expected a hashed ConfigMap name, got %q", name)
+	}
+	if _, ok := cm.GetAnnotations()["kustomize.config.k8s.io/needs-hash"]; ok {
+		t.Error("Internal annotation wasn't removed")
+	}
+	data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+	if data["LOG_LEVEL"] != "info" || data["nginx.conf"] != "listen 80;\n" {
+		t.Errorf("Unexpected ConfigMap data: %v", data)
+	}
+	svc := m.Filter(ByKind("Service")).Resources()[0]
+	if selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector"); selector["app"] != "web" {
+		t.Errorf("Expected commonLabels in Service selector, got %v", selector)
+	}
+}
+
+func TestKustomizeOverlay(t *testing.T) {
+	m, err := ManifestFrom(Kustomize("testdata/kustomize/overlay"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Resources()) != 5 {
+		t.Fatalf("Expected 5 resources, got %d", len(m.Resources()))
+	}
+	for _, u := range m.Resources() {
+		if u.GetNamespace() != "prod" {
+			t.Errorf("Expected namespace prod for %s, got %q", u.GetName(), u.GetNamespace())
+		}
+		if !strings.HasPrefix(u.GetName(), "prod-") {
+			t.Errorf("Expected prefix prod- for %s", u.GetName())
+		}
+		if labels := u.GetLabels(); labels["env"] != "prod" || (u.GetKind() != "Secret" && labels["app"] != "web") {
+			t.Errorf("Expected common labels for %s, got %v", u.GetName(), labels)
+		}
+		if u.GetAnnotations()["owner"] != "platform" {
+			t.Errorf("Expected common annotation for %s", u.GetName())
+		}
+	}
+	cm := m.Filter(ByKind("ConfigMap")).Resources()[0]
+	if data, _, _ := unstructured.NestedStringMap(cm.Object, "data"); data["LOG_LEVEL"] != "warn" || data["nginx.conf"] == "" {
+		t.Errorf("Expected merged ConfigMap data, got %v", data)
+	}
+	secret := m.Filter(ByKind("Secret")).Resources()[0]
+
+	deployment := m.Filter(ByKind("Deployment")).Resources()[0]
+	if deployment.GetName() != "prod-web" {
+		t.Errorf("Expected name prod-web, got %q", deployment.GetName())
+	}
+	if replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas"); replicas != 5 {
+		t.Errorf("Expected 5 replicas, got %d", replicas)
+	}
+	selector, _, _ := unstructured.NestedStringMap(deployment.Object, "spec", "selector", "matchLabels")
+	template, _, _ := unstructured.NestedStringMap(deployment.Object, "spec", "template", "metadata", "labels")
+	if selector["env"] != "prod" || template["env"] != "prod" {
+		t.Errorf("Expected labels in selector and template, got %v and %v", selector, template)
+	}
+	if deployment.GetLabels()["team"] != "web" || template["team"] != "web" || selector["team"] != "" {
+		t.Errorf("Expected labels in metadata and template only, got %v and %v", selector, template)
+	}
+	spec := deployment.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	if sa := spec["serviceAccountName"]; sa != "prod-web" {
+		t.Errorf("Expected serviceAccountName prod-web, got %q", sa)
+	}
+	container := spec["containers"].([]interface{})[0].(map[string]interface{})
+	if image := container["image"]; image != "mirror.local/nginx:1.25" {
+		t.Errorf("Expected patched and renamed image, got %q", image)
+	}
+	envFrom := container["envFrom"].([]interface{})
+	if name, _, _ := unstructured.NestedString(envFrom[0].(map[string]interface{}), "configMapRef", "name"); name != cm.GetName() {
+		t.Errorf("Expected reference to %q, got %q", cm.GetName(), name)
+	}
+	if name, _, _ := unstructured.NestedString(envFrom[1].(map[string]interface{}), "secretRef", "name"); name != secret.GetName() {
+		t.Errorf("Expected reference to %q, got %q", secret.GetName(), name)
+	}
+	volume := spec["volumes"].([]interface{})[0].(map[string]interface{})
+	if name, _, _ := unstructured.NestedString(volume, "configMap", "name"); name != cm.GetName() {
+		t.Errorf("Expected volume reference to %q, got %q", cm.GetName(), name)
+	}
+
+	svc := m.Filter(ByKind("Service")).Resources()[0]
+	if typ, _, _ := unstructured.NestedString(svc.Object, "spec", "type"); typ != "NodePort" {
+		t.Errorf("Expected JSON patch to set NodePort, got %q", typ)
+	}
+}
+
+func TestKustomizeErrors(t *testing.T) {
+	if _, err := ManifestFrom(Kustomize("testdata/tree")); err == nil {
+		t.Error("Expected an error for a directory lacking a kustomization")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte("components:\n- ../component\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ManifestFrom(Kustomize(dir)); err == nil || !strings.Contains(err.Error(), "components") {
+		t.Errorf("Expected an error for an unsupported field, got %v", err)
+	}
+}
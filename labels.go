@@ -0,0 +1,62 @@
+package manifestival
+
+import (
+	"github.com/manifestival/manifestival/internal/kustomize"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MetadataOption configures InjectLabels and InjectAnnotations
+type MetadataOption func(*metadataOptions)
+
+type metadataOptions struct {
+	templates bool
+	selectors bool
+}
+
+// IncludeTemplates adds labels or annotations to the pod templates of
+// workloads, and labels to the volume claim templates of StatefulSets
+var IncludeTemplates MetadataOption = func(o *metadataOptions) {
+	o.templates = true
+}
+
+// IncludeSelectors adds labels to the selectors of Services,
+// workloads, PodDisruptionBudgets and NetworkPolicies. Since those
+// selectors must continue to match their pods, it implies
+// IncludeTemplates. Beware that the selectors of most workloads are
+// immutable, so this is best used only for new installations.
+var IncludeSelectors MetadataOption = func(o *metadataOptions) {
+	o.templates = true
+	o.selectors = true
+}
+
+// InjectLabels creates a Transformer which adds labels to the
+// metadata of every resource and, if the options allow, to their
+// templates and selectors, much like kustomize's commonLabels when
+// both options are given.
+func InjectLabels(labels map[string]string, opts ...MetadataOption) Transformer {
+	o := newMetadataOptions(opts)
+	return func(u *unstructured.Unstructured) error {
+		kustomize.AddLabels(u, labels, o.templates, o.selectors)
+		return nil
+	}
+}
+
+// InjectAnnotations creates a Transformer which adds annotations to
+// the metadata of every resource and, with IncludeTemplates, to the
+// pod templates of workloads, much like kustomize's commonAnnotations
+// in that case.
+func InjectAnnotations(annotations map[string]string, opts ...MetadataOption) Transformer {
+	o := newMetadataOptions(opts)
+	return func(u *unstructured.Unstructured) error {
+		kustomize.AddAnnotations(u, annotations, o.templates)
+		return nil
+	}
+}
+
+func newMetadataOptions(opts []MetadataOption) *metadataOptions {
+	result := &metadataOptions{}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
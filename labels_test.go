@@ -0,0 +1,141 @@
+package manifestival_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+const selected = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: web
+spec:
+  podSelector:
+    matchLabels:
+      app: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`
+
+func TestInjectLabels(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/part-of": "shop"}
+	fields := map[string][]string{
+		"Deployment/template":          {"spec", "template", "metadata", "labels"},
+		"Deployment/selector":          {"spec", "selector", "matchLabels"},
+		"Service/selector":             {"spec", "selector"},
+		"PodDisruptionBudget/selector": {"spec", "selector", "matchLabels"},
+		"NetworkPolicy/selector":       {"spec", "podSelector", "matchLabels"},
+	}
+	tests := []struct {
+		name string
+		opts []MetadataOption
+		want []string
+	}{{
+		name: "metadata",
+	}, {
+		name: "templates",
+		opts: []MetadataOption{IncludeTemplates},
+		want: []string{"Deployment/template"},
+	}, {
+		name: "selectors",
+		opts: []MetadataOption{IncludeSelectors},
+		want: []string{"Deployment/template", "Deployment/selector", "Service/selector", "PodDisruptionBudget/selector", "NetworkPolicy/selector"},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := ManifestFrom(Reader(strings.NewReader(selected)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m, err = m.Transform(InjectLabels(labels, test.opts...)); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]bool{}
+			for _, field := range test.want {
+				want[field] = true
+			}
+			for _, u := range m.Resources() {
+				if u.GetLabels()["app.kubernetes.io/part-of"] != "shop" {
+					t.Errorf("%s lacks label: %v", u.GetKind(), u.GetLabels())
+				}
+				for field, path := range fields {
+					if !strings.HasPrefix(field, u.GetKind()+"/") {
+						continue
+					}
+					got, _, _ := unstructured.NestedStringMap(u.Object, path...)
+					if got["app"] != "web" {
+						t.Errorf("%s: existing label removed: %v", field, got)
+					}
+					if _, ok := got["app.kubernetes.io/part-of"]; ok != want[field] {
+						t.Errorf("%s: labeled = %v, want %v", field, ok, want[field])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInjectAnnotations(t *testing.T) {
+	annotations := map[string]string{"example.com/owner": "team"}
+	for _, templates := range []bool{false, true} {
+		m, err := ManifestFrom(Reader(strings.NewReader(selected)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := []MetadataOption{}
+		if templates {
+			opts = append(opts, IncludeTemplates)
+		}
+		if m, err = m.Transform(InjectAnnotations(annotations, opts...)); err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range m.Resources() {
+			if u.GetAnnotations()["example.com/owner"] != "team" {
+				t.Errorf("%s lacks annotation: %v", u.GetKind(), u.GetAnnotations())
+			}
+		}
+		deployment := m.Filter(ByKind("Deployment")).Resources()[0]
+		got, _, _ := unstructured.NestedStringMap(deployment.Object, "spec", "template", "metadata", "annotations")
+		if _, ok := got["example.com/owner"]; ok != templates {
+			t.Errorf("Template annotated = %v, want %v", ok, templates)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package manifestival
+
+import (
+	"github.com/manifestival/manifestival/internal/refs"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AffixNames creates a Transformer which adds a prefix and suffix to
+// the names of the resources in m, rewriting the references to them.
+func AffixNames(prefix, suffix string, m Manifest) Transformer {
+	renames := affixes(m.resources, prefix, suffix)
+	return func(u *unstructured.Unstructured) error {
+		if name, ok := renames.Rename(u.GetKind(), u.GetNamespace(), u.GetName()); ok {
+			u.SetName(name)
+		}
+		refs.Update(u, renames.Rename)
+		return nil
+	}
+}
+
+// affixes returns the new names of the resources to be renamed with
+// a prefix and suffix
+func affixes(resources []unstructured.Unstructured, prefix, suffix string) refs.Renames {
+	renames := refs.Renames{}
+	if prefix == "" && suffix == "" {
+		return renames
+	}
+	for i := range resources {
+		u := &resources[i]
+		switch u.GetKind() {
+		case "CustomResourceDefinition", "APIService", "Namespace":
+			continue
+		}
+		renames.Add(u.GetKind(), u.GetNamespace(), u.GetName(), prefix+u.GetName()+suffix)
+	}
+	return renames
+}
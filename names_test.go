@@ -0,0 +1,204 @@
+package manifestival_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+const named = `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: controller
+  namespace: system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: system
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: webhook
+  namespace: system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+  namespace: system
+spec:
+  template:
+    spec:
+      serviceAccountName: controller
+      containers:
+      - name: controller
+        envFrom:
+        - configMapRef:
+            name: config
+        - secretRef:
+            name: external
+      volumes:
+      - name: config
+        configMap:
+          name: config
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: leader-election
+  namespace: system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: leader-election
+  namespace: system
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: leader-election
+subjects:
+- kind: ServiceAccount
+  name: controller
+  namespace: system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: admin
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- kind: ServiceAccount
+  name: controller
+  namespace: system
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validation
+webhooks:
+- name: validation.example.com
+  clientConfig:
+    service:
+      name: webhook
+      namespace: system
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`
+
+func TestAffixNames(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(named)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err = m.Transform(AffixNames("blue-", "-v2", m)); err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]string{}
+	for _, u := range m.Resources() {
+		names[u.GetKind()] = u.GetName()
+	}
+	for kind, want := range map[string]string{
+		"ServiceAccount":                 "blue-controller-v2",
+		"Deployment":                     "blue-controller-v2",
+		"RoleBinding":                    "blue-leader-election-v2",
+		"ClusterRoleBinding":             "blue-admin-v2",
+		"ValidatingWebhookConfiguration": "blue-validation-v2",
+		"CustomResourceDefinition":       "widgets.example.com",
+	} {
+		if names[kind] != want {
+			t.Errorf("%s name = %q, want %q", kind, names[kind], want)
+		}
+	}
+
+	refs := []struct {
+		kind string
+		path []string
+		want string
+	}{
+		{"Deployment", []string{"spec", "template", "spec", "serviceAccountName"}, "blue-controller-v2"},
+		{"RoleBinding", []string{"roleRef", "name"}, "blue-leader-election-v2"},
+		{"ClusterRoleBinding", []string{"roleRef", "name"}, "cluster-admin"},
+	}
+	for _, ref := range refs {
+		u := m.Filter(ByKind(ref.kind)).Resources()[0]
+		if got, _, _ := unstructured.NestedString(u.Object, ref.path...); got != ref.want {
+			t.Errorf("%s %v = %q, want %q", ref.kind, ref.path, got, ref.want)
+		}
+	}
+	deployment := m.Filter(ByKind("Deployment")).Resources()[0]
+	spec, _, _ := unstructured.NestedMap(deployment.Object, "spec", "template", "spec")
+	container := spec["containers"].([]interface{})[0].(map[string]interface{})
+	envFrom := container["envFrom"].([]interface{})
+	if got := envFrom[0].(map[string]interface{})["configMapRef"].(map[string]interface{})["name"]; got != "blue-config-v2" {
+		t.Errorf("configMapRef = %v", got)
+	}
+	if got := envFrom[1].(map[string]interface{})["secretRef"].(map[string]interface{})["name"]; got != "external" {
+		t.Errorf("Expected reference to an external secret to be unchanged, got %v", got)
+	}
+	if got := spec["volumes"].([]interface{})[0].(map[string]interface{})["configMap"].(map[string]interface{})["name"]; got != "blue-config-v2" {
+		t.Errorf("volume configMap = %v", got)
+	}
+	for _, kind := range []string{"RoleBinding", "ClusterRoleBinding"} {
+		u := m.Filter(ByKind(kind)).Resources()[0]
+		subjects, _, _ := unstructured.NestedSlice(u.Object, "subjects")
+		if got := subjects[0].(map[string]interface{})["name"]; got != "blue-controller-v2" {
+			t.Errorf("%s subject = %v", kind, got)
+		}
+	}
+	hook := m.Filter(ByKind("ValidatingWebhookConfiguration")).Resources()[0]
+	webhooks, _, _ := unstructured.NestedSlice(hook.Object, "webhooks")
+	if got, _, _ := unstructured.NestedString(webhooks[0].(map[string]interface{}), "clientConfig", "service", "name"); got != "blue-webhook-v2" {
+		t.Errorf("webhook service = %v", got)
+	}
+}
+
+func TestAffixNamesAfterInjectNamespace(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      volumes:
+      - name: config
+        configMap:
+          name: config
+`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err = m.Transform(InjectNamespace("foo"), AffixNames("p-", "", m)); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range m.Resources() {
+		if !strings.HasPrefix(u.GetName(), "p-") || u.GetNamespace() != "foo" {
+			t.Errorf("%s %s/%s wasn't renamed", u.GetKind(), u.GetNamespace(), u.GetName())
+		}
+	}
+	deployment := m.Filter(ByKind("Deployment")).Resources()[0]
+	volumes, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "volumes")
+	if got, _, _ := unstructured.NestedString(volumes[0].(map[string]interface{}), "configMap", "name"); got != "p-config" {
+		t.Errorf("volume configMap = %q, want p-config", got)
+	}
+}
@@ -0,0 +1,41 @@
+package manifestival
+
+import (
+	"github.com/manifestival/manifestival/internal/oci"
+	"github.com/manifestival/manifestival/internal/sources"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// OCI is a Source comprised of the manifests within an artifact in an
+// OCI image layout, either a directory or a tarball of one, such as
+// those written by `oras copy --to-oci-layout` or `crane pull
+// --format=oci`. The Reference selects the artifact by its tag, i.e.
+// its org.opencontainers.image.ref.name annotation, or digest, and
+// may be omitted if the layout contains only one. If MediaTypes are
+// given, only layers whose media types match one of them, as
+// understood by path.Match, are read. Layers that are tar or zip
+// archives contribute the YAML and JSON files within them, and other
+// layers are parsed whole. Every blob's digest is verified.
+type OCI struct {
+	Path       string
+	Reference  string
+	MediaTypes []string
+}
+
+var _ RawSource = OCI{}
+
+func (o OCI) Parse() ([]unstructured.Unstructured, error) {
+	docs, err := o.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return sources.DecodeAll(docs)
+}
+
+func (o OCI) Raw() ([][]byte, error) {
+	layout, err := oci.Open(o.Path)
+	if err != nil {
+		return nil, err
+	}
+	return layout.Read(o.Reference, o.MediaTypes...)
+}
@@ -0,0 +1,49 @@
+package manifestival_test
+
+import (
+	"testing"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestOCI(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    OCI
+		want      int
+		wantError bool
+	}{{
+		name:   "directory",
+		source: OCI{Path: "internal/oci/testdata/oci", Reference: "v1"},
+		want:   3,
+	}, {
+		name:   "tarball",
+		source: OCI{Path: "internal/oci/testdata/oci.tar", Reference: "v2"},
+		want:   1,
+	}, {
+		name:   "media types",
+		source: OCI{Path: "internal/oci/testdata/oci", Reference: "v1", MediaTypes: []string{"application/vnd.cncf.flux.content.v1.tar+gzip"}},
+		want:   2,
+	}, {
+		name:      "ambiguous",
+		source:    OCI{Path: "internal/oci/testdata/oci"},
+		wantError: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := ManifestFrom(test.source)
+			if test.wantError {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Resources()) != test.want {
+				t.Errorf("Got %d resources, want %d", len(m.Resources()), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package manifestival
+
+import (
+	"fmt"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// OwnerOption configures InjectOwner
+type OwnerOption func(*ownerOptions)
+
+type ownerOptions struct {
+	append             bool
+	controller         bool
+	blockOwnerDeletion bool
+	skipClusterScoped  bool
+	skipNamespaces     bool
+	scope              *namespaceOptions
+}
+
+// AppendOwner adds the owner to any existing OwnerReferences rather
+// than replacing them. A reference to the same owner, by UID, is
+// updated in place. Since a resource may have only one controller,
+// it's an error to add a controller to a resource that has another.
+var AppendOwner OwnerOption = func(o *ownerOptions) {
+	o.append = true
+}
+
+// SkipOtherNamespaces leaves alone the resources in namespaces other
+// than that of a namespaced owner, which can't refer to it
+var SkipOtherNamespaces OwnerOption = func(o *ownerOptions) {
+	o.skipNamespaces = true
+}
+
+// Controller sets whether the owner is the controller of the
+// resources, true by default
+func Controller(controller bool) OwnerOption {
+	return func(o *ownerOptions) {
+		o.controller = controller
+	}
+}
+
+// BlockOwnerDeletion sets whether the owner can't be deleted in the
+// foreground until the resources are, true by default
+func BlockOwnerDeletion(block bool) OwnerOption {
+	return func(o *ownerOptions) {
+		o.blockOwnerDeletion = block
+	}
+}
+
+// SkipClusterScoped leaves alone cluster-scoped resources when the
+// owner is namespaced, which the API server would reject. Their scope
+// is determined as it is by InjectNamespace with the same resolvers.
+func SkipClusterScoped(resolvers ...ScopeResolver) OwnerOption {
+	return func(o *ownerOptions) {
+		o.skipClusterScoped = true
+		o.scope.resolvers = append(o.scope.resolvers, resolvers...)
+	}
+}
+
+func newOwnerOptions(opts []OwnerOption) *ownerOptions {
+	result := &ownerOptions{
+		controller:         true,
+		blockOwnerDeletion: true,
+		scope:              newNamespaceOptions(nil),
+	}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
+
+// skip returns true if u shouldn't refer to owner
+func (o *ownerOptions) skip(owner Owner, u *unstructured.Unstructured) bool {
+	if owner.GetNamespace() == "" {
+		return false
+	}
+	if o.skipNamespaces && u.GetNamespace() != "" && u.GetNamespace() != owner.GetNamespace() {
+		return true
+	}
+	return o.skipClusterScoped && o.scope.isClusterScoped(u.GroupVersionKind())
+}
+
+// reference returns the OwnerReference to owner
+func (o *ownerOptions) reference(owner Owner) v1.OwnerReference {
+	controller, block := o.controller, o.blockOwnerDeletion
+	ref := *v1.NewControllerRef(owner, owner.GroupVersionKind())
+	ref.Controller = &controller
+	ref.BlockOwnerDeletion = &block
+	return ref
+}
+
+// references returns the OwnerReferences of u with ref added
+func (o *ownerOptions) references(u *unstructured.Unstructured, ref v1.OwnerReference) ([]v1.OwnerReference, error) {
+	if !o.append {
+		return []v1.OwnerReference{ref}, nil
+	}
+	result := []v1.OwnerReference{}
+	found := false
+	for _, existing := range u.GetOwnerReferences() {
+		switch {
+		case existing.UID == ref.UID:
+			existing, found = ref, true
+		case *ref.Controller && existing.Controller != nil && *existing.Controller:
+			return nil, fmt.Errorf("%s %s is already controlled by %s %s", u.GetKind(), u.GetName(), existing.Kind, existing.Name)
+		}
+		result = append(result, existing)
+	}
+	if !found {
+		result = append(result, ref)
+	}
+	return result, nil
+}
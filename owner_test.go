@@ -0,0 +1,139 @@
+package manifestival_test
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	. "github.com/manifestival/manifestival"
+)
+
+const owned = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: owned
+  namespace: system
+  ownerReferences:
+  - apiVersion: v1
+    kind: ConfigMap
+    name: other
+    uid: other-uid
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: elsewhere
+  namespace: other
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: role
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+`
+
+func TestInjectOwnerOptions(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(owned)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "system", UID: types.UID("owner-uid")},
+	}
+	widgetIsClusterScoped := func(gvk schema.GroupVersionKind) (bool, bool) {
+		return gvk.Kind == "Widget", gvk.Kind == "Widget"
+	}
+
+	tests := []struct {
+		name string
+		opts []OwnerOption
+		// The number of references of each resource, by name
+		want map[string]int
+	}{{
+		name: "replace",
+		want: map[string]int{"owned": 1, "elsewhere": 1, "role": 1, "widget": 1},
+	}, {
+		name: "append",
+		opts: []OwnerOption{AppendOwner},
+		want: map[string]int{"owned": 2, "elsewhere": 1, "role": 1, "widget": 1},
+	}, {
+		name: "skip other namespaces",
+		opts: []OwnerOption{SkipOtherNamespaces},
+		want: map[string]int{"owned": 1, "elsewhere": 0, "role": 1, "widget": 1},
+	}, {
+		name: "skip cluster-scoped",
+		opts: []OwnerOption{SkipClusterScoped(widgetIsClusterScoped)},
+		want: map[string]int{"owned": 1, "elsewhere": 1, "role": 0, "widget": 0},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := m.Transform(InjectOwner(owner, test.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, u := range result.Resources() {
+				refs := u.GetOwnerReferences()
+				if len(refs) != test.want[u.GetName()] {
+					t.Errorf("%s has %d references, want %d", u.GetName(), len(refs), test.want[u.GetName()])
+				}
+				if len(refs) > 0 && refs[len(refs)-1].UID != owner.UID {
+					t.Errorf("Expected %s to refer to the owner last, got %v", u.GetName(), refs)
+				}
+			}
+		})
+	}
+}
+
+func TestInjectOwnerFlags(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(owned)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = m.Filter(ByName("owned"))
+	owner := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "system", UID: types.UID("owner-uid")},
+	}
+	m, err = m.Transform(InjectOwner(owner, AppendOwner, Controller(false), BlockOwnerDeletion(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := m.Resources()[0].GetOwnerReferences()
+	ref := refs[1]
+	if *ref.Controller || *ref.BlockOwnerDeletion {
+		t.Errorf("Expected flags to be false, got %v", ref)
+	}
+	if refs[0].UID != "other-uid" {
+		t.Errorf("Expected the existing reference to be kept, got %v", refs)
+	}
+
+	// Appending again updates the existing reference
+	m, err = m.Transform(InjectOwner(owner, AppendOwner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs = m.Resources()[0].GetOwnerReferences()
+	if len(refs) != 2 || !*refs[1].Controller {
+		t.Errorf("Expected the reference to the owner to be updated, got %v", refs)
+	}
+
+	// A second controller is an error
+	other := owner.DeepCopy()
+	other.UID = "another-uid"
+	if _, err := m.Transform(InjectOwner(other, AppendOwner)); err == nil || !strings.Contains(err.Error(), "already controlled by Deployment owner") {
+		t.Errorf("Expected an error adding a second controller, got %v", err)
+	}
+	if _, err := m.Transform(InjectOwner(other, AppendOwner, Controller(false))); err != nil {
+		t.Errorf("Expected a non-controller reference to be added, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package manifestival
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/manifestival/manifestival/internal/kustomize"
+	"github.com/manifestival/manifestival/internal/patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// resourcePatch is either a strategic merge or a JSON 6902 patch
+type resourcePatch struct {
+	ops       jsonpatch.Patch
+	strategic map[string]interface{}
+	// target is the resource identified by a strategic merge patch,
+	// nil if it lacks a kind or name
+	target *kustomize.Selector
+}
+
+// Patch creates a Transformer which applies the strategic merge or
+// JSON 6902 patches in data, a stream of YAML or JSON documents, to the
+// resources for which no Predicate returns false.
+func Patch(data []byte, preds ...Predicate) Transformer {
+	patches, err := decodePatches([][]byte{data})
+	return patchTransformer(patches, err, preds)
+}
+
+// Patch is like the function of the same name, but fails if a patch
+// identifying its target matches no resource in m selected by preds
+func (m Manifest) Patch(data []byte, preds ...Predicate) Transformer {
+	patches, err := decodePatches([][]byte{data})
+	if err == nil {
+		err = m.matchPatches(patches, preds)
+	}
+	return patchTransformer(patches, err, preds)
+}
+
+// PatchFrom is like Patch, but reads the patches from src, e.g. a
+// Path to the patches kept alongside a manifest. JSON 6902 patches
+// may only be read from a RawSource; the resources parsed from any
+// other Source are applied as strategic merge patches.
+func PatchFrom(src Source, preds ...Predicate) Transformer {
+	patches, err := readPatches(src)
+	return patchTransformer(patches, err, preds)
+}
+
+// PatchFrom is like the function of the same name, but fails if a
+// patch identifying its target matches no resource in m selected by
+// preds
+func (m Manifest) PatchFrom(src Source, preds ...Predicate) Transformer {
+	patches, err := readPatches(src)
+	if err == nil {
+		err = m.matchPatches(patches, preds)
+	}
+	return patchTransformer(patches, err, preds)
+}
+
+// readPatches returns the patches in src
+func readPatches(src Source) ([]resourcePatch, error) {
+	if raw, ok := src.(RawSource); ok {
+		docs, err := raw.Raw()
+		if err != nil {
+			return nil, err
+		}
+		return decodePatches(docs)
+	}
+	resources, err := src.Parse()
+	if err != nil {
+		return nil, err
+	}
+	result := []resourcePatch{}
+	for _, u := range resources {
+		result = append(result, strategicPatch(u.Object))
+	}
+	return result, nil
+}
+
+// matchPatches returns an error if a patch identifying its target
+// matches no resource in m selected by preds
+func (m Manifest) matchPatches(patches []resourcePatch, preds []Predicate) error {
+	pred := All(preds...)
+	for _, p := range patches {
+		if p.target == nil {
+			continue
+		}
+		matched := false
+		for i := 0; i < len(m.resources) && !matched; i++ {
+			u := &m.resources[i]
+			ok, err := p.target.Matches(u)
+			if err != nil {
+				return err
+			}
+			matched = ok && pred(u)
+		}
+		if !matched {
+			return fmt.Errorf("no target found for patch of %s %s", p.target.Kind, p.target.Name)
+		}
+	}
+	return nil
+}
+
+func patchTransformer(patches []resourcePatch, err error, preds []Predicate) Transformer {
+	if err == nil && len(preds) == 0 {
+		for _, p := range patches {
+			if p.ops != nil {
+				err = fmt.Errorf("JSON 6902 patch requires a predicate to select its targets")
+				break
+			}
+			if p.target == nil {
+				err = fmt.Errorf("patch lacks either a kind or name to identify its target")
+				break
+			}
+		}
+	}
+	pred := All(preds...)
+	return func(u *unstructured.Unstructured) error {
+		if err != nil {
+			return err
+		}
+		if len(preds) > 0 && !pred(u) {
+			return nil
+		}
+		for _, p := range patches {
+			// a patch identifying its target applies only to it
+			if p.target != nil {
+				if ok, err := p.target.Matches(u); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+			}
+			if p.ops != nil {
+				if err := kustomize.ApplyJSON(u, p.ops); err != nil {
+					return err
+				}
+				continue
+			}
+			if p.strategic["$patch"] == "delete" {
+				return fmt.Errorf("%s %s: a Transformer can't delete a resource; use Filter instead", u.GetKind(), u.GetName())
+			}
+			data, err := json.Marshal(p.strategic)
+			if err != nil {
+				return err
+			}
+			if err := patch.Strategic(u, data); err != nil {
+				return fmt.Errorf("%s %s: %w", u.GetKind(), u.GetName(), err)
+			}
+		}
+		return nil
+	}
+}
+
+// decodePatches returns the patches in each YAML document of each
+// element of data
+func decodePatches(data [][]byte) ([]resourcePatch, error) {
+	result := []resourcePatch{}
+	for _, stream := range data {
+		reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(stream)))
+		for {
+			doc, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if isEmptyDocument(doc) {
+				continue
+			}
+			ops, obj, err := kustomize.Decode(doc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid patch: %w", err)
+			}
+			if ops != nil {
+				result = append(result, resourcePatch{ops: ops})
+				continue
+			}
+			result = append(result, strategicPatch(obj))
+		}
+	}
+	return result, nil
+}
+
+// strategicPatch returns a strategic merge patch, identifying its
+// target if it can
+func strategicPatch(obj map[string]interface{}) resourcePatch {
+	target, _ := kustomize.SelectorFor(obj)
+	return resourcePatch{strategic: kustomize.WithoutIdentity(obj), target: target}
+}
+
+// isEmptyDocument returns true if doc contains only whitespace and
+// comments
+func isEmptyDocument(doc []byte) bool {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' && !bytes.Equal(line, []byte("---")) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package manifestival_test
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestPatch(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Transform(
+		PatchFrom(Path("testdata/patches/strategic.yaml")),
+		PatchFrom(Path("testdata/patches/json6902.yaml"), ByKind("StatefulSet")),
+		Patch([]byte(`{"spec": {"template": {"spec": {"hostNetwork": true}}}}`), ByKind("DaemonSet")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deployment := m.Filter(ByKind("Deployment")).Resources()[0]
+	if replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("replicas = %d, want 3", replicas)
+	}
+	if deployment.GetAnnotations()["patched"] != "true" {
+		t.Errorf("Expected patched annotation, got %v", deployment.GetAnnotations())
+	}
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	if len(containers) != 2 {
+		t.Fatalf("Expected containers to be merged by name, got %v", containers)
+	}
+	app := containers[0].(map[string]interface{})
+	if app["image"] != "gcr.io/project/app:v1" || app["env"] == nil {
+		t.Errorf("Expected env merged into app container, got %v", app)
+	}
+
+	statefulset := m.Filter(ByKind("StatefulSet")).Resources()[0]
+	if replicas, _, _ := unstructured.NestedInt64(statefulset.Object, "spec", "replicas"); replicas != 2 {
+		t.Errorf("StatefulSet replicas = %d, want 2", replicas)
+	}
+	if got := containerImages(m.Resources())["StatefulSet/db"]; got != "postgres:16" {
+		t.Errorf("db image = %q, want postgres:16", got)
+	}
+	if statefulset.GetLabels()["patched"] != "true" {
+		t.Errorf("Expected patched label, got %v", statefulset.GetLabels())
+	}
+
+	daemonset := m.Filter(ByKind("DaemonSet")).Resources()[0]
+	if host, _, _ := unstructured.NestedBool(daemonset.Object, "spec", "template", "spec", "hostNetwork"); !host {
+		t.Error("Expected hostNetwork to be set on the DaemonSet")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(m.Filter(ByKind("ReplicaSet")).Resources()[0].Object, "spec", "template", "spec", "hostNetwork"); found {
+		t.Error("Expected the ReplicaSet to be unpatched")
+	}
+}
+
+func TestPatchSlice(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patches, err := ManifestFrom(Path("testdata/patches/strategic.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Not a RawSource, so the patches are the parsed resources
+	m, err = m.Transform(PatchFrom(Slice(patches.Resources())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statefulset := m.Filter(ByKind("StatefulSet")).Resources()[0]
+	if replicas, _, _ := unstructured.NestedInt64(statefulset.Object, "spec", "replicas"); replicas != 2 {
+		t.Errorf("StatefulSet replicas = %d, want 2", replicas)
+	}
+}
+
+func TestPatchTargets(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched, err := m.Transform(PatchFrom(Path("testdata/patches/strategic.yaml"), ByKind("StatefulSet")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statefulset := patched.Filter(ByKind("StatefulSet")).Resources()[0]
+	if replicas, _, _ := unstructured.NestedInt64(statefulset.Object, "spec", "replicas"); replicas != 2 {
+		t.Errorf("StatefulSet replicas = %d, want 2", replicas)
+	}
+	if statefulset.GetAnnotations()["patched"] != "" {
+		t.Errorf("Expected the Deployment's patch not to apply, got %v", statefulset.GetAnnotations())
+	}
+	deployment := patched.Filter(ByKind("Deployment")).Resources()[0]
+	if deployment.GetAnnotations()["patched"] != "" {
+		t.Errorf("Expected the Deployment not to be selected, got %v", deployment.GetAnnotations())
+	}
+
+	if _, err := m.Transform(m.PatchFrom(Path("testdata/patches/strategic.yaml"))); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	if _, err := m.Transform(m.PatchFrom(Path("testdata/patches/strategic.yaml"), ByKind("StatefulSet"))); err == nil || !strings.Contains(err.Error(), "Deployment deployment") {
+		t.Errorf("Expected an error for the unselected Deployment, got %v", err)
+	}
+	if _, err := m.Transform(m.Patch([]byte("kind: Deployment\nmetadata: {name: missing}"))); err == nil {
+		t.Error("Expected an error for a patch matching nothing")
+	}
+	if _, err := m.Transform(m.Patch([]byte(`{"spec": {"replicas": 2}}`), ByKind("Deployment"))); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		transformer Transformer
+		want        string
+	}{{
+		name:        "json patch without predicate",
+		transformer: PatchFrom(Path("testdata/patches/json6902.yaml")),
+		want:        "JSON 6902 patch requires a predicate",
+	}, {
+		name:        "unidentified strategic patch",
+		transformer: Patch([]byte(`spec: {replicas: 2}`)),
+		want:        "lacks either a kind or name",
+	}, {
+		name:        "invalid patch",
+		transformer: Patch([]byte(`"replicas"`), ByKind("Deployment")),
+		want:        "invalid patch",
+	}, {
+		name:        "failed operation",
+		transformer: Patch([]byte(`[{"op": "remove", "path": "/spec/missing"}]`), ByKind("Job")),
+		want:        "Job job",
+	}, {
+		name:        "delete",
+		transformer: Patch([]byte("$patch: delete"), ByKind("ConfigMap")),
+		want:        "use Filter instead",
+	}, {
+		name:        "missing source",
+		transformer: PatchFrom(Path("testdata/patches/missing.yaml")),
+		want:        "no such file",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := m.Transform(test.transformer)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Expected error containing %q, got %v", test.want, err)
+			}
+		})
+	}
+}
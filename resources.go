@@ -0,0 +1,115 @@
+package manifestival
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/manifestival/manifestival/internal/workloads"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ContainerResources overrides the compute resources of the
+// containers it matches. A container matches if its name equals
+// Container and its workload's name equals Workload, ignoring
+// whichever of those are empty.
+type ContainerResources struct {
+	Workload  string
+	Container string
+
+	// Scale multiplies the existing requests and limits, if non-zero,
+	// rounding up, e.g. 1.5 scales 100m of CPU to 150m
+	Scale float64
+	// Requests and Limits replace the existing quantities of the
+	// same resources, or are added to them
+	Requests corev1.ResourceList
+	Limits   corev1.ResourceList
+	// DefaultsOnly adds the Requests and Limits only for the
+	// resources that aren't already requested or limited
+	DefaultsOnly bool
+}
+
+// resourceFields are the pod spec fields containing containers whose
+// resources may be set
+var resourceFields = []string{"initContainers", "containers"}
+
+// InjectResources creates a Transformer which overrides the resource
+// requests and limits of the containers and init containers of
+// workloads. Every matching override is applied in order, so a general
+// default may precede overrides for particular containers.
+func InjectResources(overrides ...ContainerResources) Transformer {
+	return func(u *unstructured.Unstructured) error {
+		spec, ok := workloads.PodSpec(u)
+		if !ok {
+			return nil
+		}
+		for _, field := range resourceFields {
+			containers, _ := spec[field].([]interface{})
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, override := range overrides {
+					if !override.matches(u.GetName(), container) {
+						continue
+					}
+					if err := override.apply(container); err != nil {
+						return fmt.Errorf("%s %s: container %v: %w", u.GetKind(), u.GetName(), container["name"], err)
+					}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func (r ContainerResources) matches(workload string, container map[string]interface{}) bool {
+	return (r.Workload == "" || r.Workload == workload) &&
+		(r.Container == "" || r.Container == container["name"])
+}
+
+func (r ContainerResources) apply(container map[string]interface{}) error {
+	resources, _ := container["resources"].(map[string]interface{})
+	if resources == nil {
+		resources = map[string]interface{}{}
+	}
+	for field, values := range map[string]corev1.ResourceList{"requests": r.Requests, "limits": r.Limits} {
+		quantities, _ := resources[field].(map[string]interface{})
+		if quantities == nil {
+			quantities = map[string]interface{}{}
+		}
+		if r.Scale != 0 {
+			for name, v := range quantities {
+				q, err := resource.ParseQuantity(fmt.Sprint(v))
+				if err != nil {
+					return fmt.Errorf("invalid %s of %s: %w", field, name, err)
+				}
+				quantities[name] = scale(corev1.ResourceName(name), q, r.Scale).String()
+			}
+		}
+		for name, q := range values {
+			if _, found := quantities[string(name)]; found && r.DefaultsOnly {
+				continue
+			}
+			quantities[string(name)] = q.String()
+		}
+		if len(quantities) > 0 {
+			resources[field] = quantities
+		}
+	}
+	if len(resources) > 0 {
+		container["resources"] = resources
+	}
+	return nil
+}
+
+// scale multiplies a quantity by factor, rounding up to the nearest
+// millicore of CPU or unit of anything else
+func scale(name corev1.ResourceName, q resource.Quantity, factor float64) *resource.Quantity {
+	if name == corev1.ResourceCPU {
+		return resource.NewMilliQuantity(int64(math.Ceil(float64(q.MilliValue())*factor)), q.Format)
+	}
+	return resource.NewQuantity(int64(math.Ceil(float64(q.Value())*factor)), q.Format)
+}
@@ -0,0 +1,103 @@
+package manifestival_test
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestInjectResources(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Transform(SetField(".spec.template.spec.containers[0].resources", map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "100m", "memory": "1Gi"},
+		"limits":   map[string]interface{}{"cpu": 1},
+	}, ByKind("StatefulSet")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Transform(InjectResources(
+		ContainerResources{
+			Requests:     corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+			DefaultsOnly: true,
+		},
+		ContainerResources{Workload: "statefulset", Scale: 1.5},
+		ContainerResources{Workload: "deployment", Container: "sidecar", Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")}},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kind      string
+		container string
+		want      map[string]interface{}
+	}{{
+		kind:      "StatefulSet",
+		container: "db",
+		want: map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "150m", "memory": "1536Mi"},
+			"limits":   map[string]interface{}{"cpu": "1500m"},
+		},
+	}, {
+		kind:      "Deployment",
+		container: "sidecar",
+		want: map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "50m", "memory": "64Mi"},
+			"limits":   map[string]interface{}{"memory": "128Mi"},
+		},
+	}, {
+		kind:      "Deployment",
+		container: "app",
+		want: map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "50m", "memory": "64Mi"},
+		},
+	}, {
+		kind:      "CronJob",
+		container: "backup",
+		want: map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "50m", "memory": "64Mi"},
+		},
+	}, {
+		kind:      "Pod",
+		container: "init",
+		want: map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "50m", "memory": "64Mi"},
+		},
+	}}
+	for _, test := range tests {
+		u := m.Filter(ByKind(test.kind)).Resources()[0]
+		spec := podSpec(t, &u)
+		var got interface{}
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _, _ := unstructured.NestedSlice(spec, field)
+			for _, c := range containers {
+				if c := c.(map[string]interface{}); c["name"] == test.container {
+					got = c["resources"]
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %s resources = %v, want %v", test.kind, test.container, got, test.want)
+		}
+	}
+
+	// Ephemeral containers may not have resources
+	pod := m.Filter(ByKind("Pod")).Resources()[0]
+	ephemeral, _, _ := unstructured.NestedSlice(pod.Object, "spec", "ephemeralContainers")
+	if _, found := ephemeral[0].(map[string]interface{})["resources"]; found {
+		t.Error("Expected ephemeral container to be unchanged")
+	}
+
+	invalid, _ := m.Transform(SetField(".spec.template.spec.containers[0].resources.limits.cpu", "lots", ByKind("DaemonSet")))
+	if _, err := invalid.Transform(InjectResources(ContainerResources{Scale: 2})); err == nil {
+		t.Error("Expected an error scaling an invalid quantity")
+	}
+}
@@ -0,0 +1,137 @@
+package manifestival
+
+import (
+	"github.com/manifestival/manifestival/internal/workloads"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Scheduling constrains the nodes on which pods are scheduled
+type Scheduling struct {
+	NodeSelector              map[string]string
+	Tolerations               []corev1.Toleration
+	Affinity                  *corev1.Affinity
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint
+	PriorityClassName         string
+}
+
+// InjectScheduling creates a Transformer which merges the scheduling
+// constraints into the pod specs of workloads. The constraints for a
+// workload are s, with any fields set by the override of the same
+// name taking the place of those of s.
+//
+// The node selector's labels are added to any existing ones. A
+// toleration replaces any existing one with the same key, operator,
+// value and effect, and a topology spread constraint any with the
+// same topologyKey and whenUnsatisfiable; otherwise they're appended.
+// The node affinity, pod affinity and pod anti-affinity of the
+// affinity, and the priority class name, replace any existing ones.
+func InjectScheduling(s Scheduling, overrides map[string]Scheduling) Transformer {
+	return func(u *unstructured.Unstructured) error {
+		spec, ok := workloads.PodSpec(u)
+		if !ok {
+			return nil
+		}
+		scheduling := s
+		if override, ok := overrides[u.GetName()]; ok {
+			scheduling = scheduling.override(override)
+		}
+		return scheduling.merge(spec)
+	}
+}
+
+// override returns s with the fields set in o replacing its own
+func (s Scheduling) override(o Scheduling) Scheduling {
+	if o.NodeSelector != nil {
+		s.NodeSelector = o.NodeSelector
+	}
+	if o.Tolerations != nil {
+		s.Tolerations = o.Tolerations
+	}
+	if o.Affinity != nil {
+		s.Affinity = o.Affinity
+	}
+	if o.TopologySpreadConstraints != nil {
+		s.TopologySpreadConstraints = o.TopologySpreadConstraints
+	}
+	if o.PriorityClassName != "" {
+		s.PriorityClassName = o.PriorityClassName
+	}
+	return s
+}
+
+// merge merges the constraints into a pod spec
+func (s Scheduling) merge(spec map[string]interface{}) error {
+	if len(s.NodeSelector) > 0 {
+		selector, _ := spec["nodeSelector"].(map[string]interface{})
+		if selector == nil {
+			selector = map[string]interface{}{}
+		}
+		for k, v := range s.NodeSelector {
+			selector[k] = v
+		}
+		spec["nodeSelector"] = selector
+	}
+	for i := range s.Tolerations {
+		toleration, err := toUnstructured(&s.Tolerations[i])
+		if err != nil {
+			return err
+		}
+		mergeList(spec, "tolerations", toleration, func(existing map[string]interface{}) bool {
+			for _, k := range []string{"key", "operator", "value", "effect"} {
+				if existing[k] != toleration[k] {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	if s.Affinity != nil {
+		affinity, err := toUnstructured(s.Affinity)
+		if err != nil {
+			return err
+		}
+		existing, _ := spec["affinity"].(map[string]interface{})
+		if existing == nil {
+			existing = map[string]interface{}{}
+		}
+		for k, v := range affinity {
+			existing[k] = v
+		}
+		spec["affinity"] = existing
+	}
+	for i := range s.TopologySpreadConstraints {
+		constraint, err := toUnstructured(&s.TopologySpreadConstraints[i])
+		if err != nil {
+			return err
+		}
+		mergeList(spec, "topologySpreadConstraints", constraint, func(existing map[string]interface{}) bool {
+			return existing["topologyKey"] == constraint["topologyKey"] &&
+				existing["whenUnsatisfiable"] == constraint["whenUnsatisfiable"]
+		})
+	}
+	if s.PriorityClassName != "" {
+		spec["priorityClassName"] = s.PriorityClassName
+		// The admission controller rejects a priority that disagrees
+		delete(spec, "priority")
+	}
+	return nil
+}
+
+// mergeList replaces the first element of a list in obj that matches
+// value, or appends value if none does
+func mergeList(obj map[string]interface{}, field string, value map[string]interface{}, matches func(map[string]interface{}) bool) {
+	list, _ := obj[field].([]interface{})
+	for i, e := range list {
+		if m, ok := e.(map[string]interface{}); ok && matches(m) {
+			list[i] = value
+			return
+		}
+	}
+	obj[field] = append(list, value)
+}
+
+func toUnstructured(obj interface{}) (map[string]interface{}, error) {
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
@@ -0,0 +1,105 @@
+package manifestival_test
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestInjectScheduling(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An existing toleration, which should be replaced
+	m, err = m.Transform(AppendField(".spec.template.spec.tolerations", map[string]interface{}{
+		"key": "dedicated", "operator": "Equal", "value": "platform", "effect": "NoSchedule", "tolerationSeconds": 60,
+	}, ByKind("Deployment")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	platform := Scheduling{
+		NodeSelector: map[string]string{"pool": "platform"},
+		Tolerations: []corev1.Toleration{{
+			Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "platform", Effect: corev1.TaintEffectNoSchedule,
+		}},
+		Affinity: &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64"},
+						}},
+					}},
+				},
+			},
+		},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+			MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule,
+		}},
+		PriorityClassName: "platform",
+	}
+	overrides := map[string]Scheduling{
+		"daemonset": {NodeSelector: map[string]string{"pool": "all"}, PriorityClassName: "system-node-critical"},
+	}
+	m, err = m.Transform(InjectScheduling(platform, overrides))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kind     string
+		pool     string
+		priority string
+	}{
+		{"Pod", "platform", "platform"},
+		{"Deployment", "platform", "platform"},
+		{"StatefulSet", "platform", "platform"},
+		{"DaemonSet", "all", "system-node-critical"},
+		{"ReplicaSet", "platform", "platform"},
+		{"Job", "platform", "platform"},
+		{"CronJob", "platform", "platform"},
+	}
+	for _, test := range tests {
+		u := m.Filter(ByKind(test.kind)).Resources()[0]
+		spec := podSpec(t, &u)
+		if got, _, _ := unstructured.NestedString(spec, "nodeSelector", "pool"); got != test.pool {
+			t.Errorf("%s pool = %q, want %q", test.kind, got, test.pool)
+		}
+		if got := spec["priorityClassName"]; got != test.priority {
+			t.Errorf("%s priorityClassName = %v, want %q", test.kind, got, test.priority)
+		}
+		tolerations, _, _ := unstructured.NestedSlice(spec, "tolerations")
+		if len(tolerations) != 1 {
+			t.Errorf("%s has tolerations %v, want one", test.kind, tolerations)
+		} else if _, found := tolerations[0].(map[string]interface{})["tolerationSeconds"]; found {
+			t.Errorf("Expected %s toleration to be replaced, got %v", test.kind, tolerations[0])
+		}
+		if _, found, _ := unstructured.NestedMap(spec, "affinity", "nodeAffinity"); !found {
+			t.Errorf("%s lacks node affinity", test.kind)
+		}
+		constraints, _, _ := unstructured.NestedSlice(spec, "topologySpreadConstraints")
+		if len(constraints) != 1 || constraints[0].(map[string]interface{})["maxSkew"] != int64(1) {
+			t.Errorf("%s has topology spread constraints %v", test.kind, constraints)
+		}
+	}
+	config := m.Filter(ByKind("ConfigMap")).Resources()[0]
+	if _, found := config.Object["spec"]; found {
+		t.Error("Expected ConfigMap to be unchanged")
+	}
+}
+
+// podSpec returns the pod spec of a workload from testdata/workloads
+func podSpec(t *testing.T, u *unstructured.Unstructured) map[string]interface{} {
+	t.Helper()
+	for _, path := range [][]string{{"spec"}, {"spec", "template", "spec"}, {"spec", "jobTemplate", "spec", "template", "spec"}} {
+		if spec, found, _ := unstructured.NestedMap(u.Object, path...); found && spec["containers"] != nil {
+			return spec
+		}
+	}
+	t.Fatalf("%s %s has no pod spec", u.GetKind(), u.GetName())
+	return nil
+}
@@ -0,0 +1,124 @@
+package manifestival
+
+import (
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ScopeResolver returns whether resources of a kind are
+// cluster-scoped, and false for known if it can't tell
+type ScopeResolver func(gvk schema.GroupVersionKind) (clusterScoped, known bool)
+
+// NamespaceOption configures InjectNamespace
+type NamespaceOption func(*namespaceOptions)
+
+type namespaceOptions struct {
+	resolvers []ScopeResolver
+	kinds     map[string]bool
+}
+
+// ResolveScope consults each resolver in turn to determine whether
+// a resource is cluster-scoped, and so shouldn't have a namespace
+// injected, before falling back to a list of well-known kinds
+func ResolveScope(resolvers ...ScopeResolver) NamespaceOption {
+	return func(o *namespaceOptions) {
+		o.resolvers = append(o.resolvers, resolvers...)
+	}
+}
+
+// ClusterScoped adds kinds, e.g. ClusterIssuer, to the list of
+// well-known cluster-scoped kinds
+func ClusterScoped(kinds ...string) NamespaceOption {
+	return func(o *namespaceOptions) {
+		for _, kind := range kinds {
+			o.kinds[strings.ToLower(kind)] = true
+		}
+	}
+}
+
+// RESTMapperScope resolves the scope of kinds using a RESTMapper,
+// e.g. one backed by discovery from client-go's restmapper package
+func RESTMapperScope(mapper meta.RESTMapper) ScopeResolver {
+	return func(gvk schema.GroupVersionKind) (bool, bool) {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return false, false
+		}
+		return mapping.Scope.Name() == meta.RESTScopeNameRoot, true
+	}
+}
+
+// ResourceLister lists the resources served by the API server for a
+// group version, and is implemented by client-go's discovery clients
+type ResourceLister interface {
+	ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error)
+}
+
+// DiscoveryScope resolves the scope of kinds by querying the API
+// server, once for each group version it serves. Failed queries
+// defer to other resolvers, and are retried for the next resource.
+func DiscoveryScope(client ResourceLister) ScopeResolver {
+	var mu sync.Mutex
+	cache := map[string]*metav1.APIResourceList{}
+	return func(gvk schema.GroupVersionKind) (bool, bool) {
+		gv := gvk.GroupVersion().String()
+		mu.Lock()
+		list, ok := cache[gv]
+		if !ok {
+			var err error
+			if list, err = client.ServerResourcesForGroupVersion(gv); err == nil {
+				cache[gv] = list
+			}
+		}
+		mu.Unlock()
+		if list == nil {
+			return false, false
+		}
+		for _, r := range list.APIResources {
+			if r.Kind == gvk.Kind && !strings.Contains(r.Name, "/") {
+				return !r.Namespaced, true
+			}
+		}
+		return false, false
+	}
+}
+
+// CRDScope resolves the scope of kinds defined by the
+// CustomResourceDefinitions in m
+func CRDScope(m Manifest) ScopeResolver {
+	scopes := map[schema.GroupKind]bool{}
+	for _, u := range m.Filter(CRDs).resources {
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(u.Object, "spec", "names", "kind")
+		scope, _, _ := unstructured.NestedString(u.Object, "spec", "scope")
+		if kind != "" && scope != "" {
+			scopes[schema.GroupKind{Group: group, Kind: kind}] = scope == "Cluster"
+		}
+	}
+	return func(gvk schema.GroupVersionKind) (bool, bool) {
+		clusterScoped, ok := scopes[gvk.GroupKind()]
+		return clusterScoped, ok
+	}
+}
+
+func newNamespaceOptions(opts []NamespaceOption) *namespaceOptions {
+	result := &namespaceOptions{kinds: map[string]bool{}}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
+
+func (o *namespaceOptions) isClusterScoped(gvk schema.GroupVersionKind) bool {
+	for _, resolve := range o.resolvers {
+		if clusterScoped, known := resolve(gvk); known {
+			return clusterScoped
+		}
+	}
+	return o.kinds[strings.ToLower(gvk.Kind)] || isClusterScoped(gvk.Kind)
+}
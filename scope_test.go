@@ -0,0 +1,184 @@
+package manifestival_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	. "github.com/manifestival/manifestival"
+	"github.com/manifestival/manifestival/fake"
+)
+
+const scoped = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusterissuers.cert-manager.io
+spec:
+  group: cert-manager.io
+  names:
+    kind: ClusterIssuer
+  scope: Cluster
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: certificates.cert-manager.io
+spec:
+  group: cert-manager.io
+  names:
+    kind: Certificate
+  scope: Namespaced
+---
+apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: issuer
+---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: cert
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: role
+`
+
+type lister struct {
+	calls     int
+	resources map[string][]metav1.APIResource
+}
+
+func (l *lister) ServerResourcesForGroupVersion(gv string) (*metav1.APIResourceList, error) {
+	l.calls++
+	resources, ok := l.resources[gv]
+	if !ok {
+		return nil, fmt.Errorf("the server could not find the requested resource")
+	}
+	return &metav1.APIResourceList{GroupVersion: gv, APIResources: resources}, nil
+}
+
+// mappingClient is a Client with a RESTMapper, like controller-runtime's
+type mappingClient struct {
+	*fake.Client
+	mapper meta.RESTMapper
+}
+
+func (c mappingClient) RESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+func TestInjectNamespaceScope(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(scoped)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, meta.RESTScopeNamespace)
+	discovery := &lister{resources: map[string][]metav1.APIResource{
+		"cert-manager.io/v1": {
+			{Name: "clusterissuers", Kind: "ClusterIssuer", Namespaced: false},
+			{Name: "clusterissuers/status", Kind: "ClusterIssuer", Namespaced: true},
+			{Name: "certificates", Kind: "Certificate", Namespaced: true},
+		},
+	}}
+
+	tests := []struct {
+		name string
+		opts []NamespaceOption
+		// The resources expected to have a namespace injected
+		want string
+	}{{
+		name: "well-known kinds",
+		want: "ClusterIssuer,Certificate,ConfigMap",
+	}, {
+		name: "crds",
+		opts: []NamespaceOption{ResolveScope(CRDScope(m))},
+		want: "Certificate,ConfigMap",
+	}, {
+		name: "cluster-scoped kinds",
+		opts: []NamespaceOption{ClusterScoped("clusterissuer")},
+		want: "Certificate,ConfigMap",
+	}, {
+		name: "rest mapper",
+		opts: []NamespaceOption{ResolveScope(RESTMapperScope(mapper))},
+		want: "Certificate,ConfigMap",
+	}, {
+		name: "discovery",
+		opts: []NamespaceOption{ResolveScope(DiscoveryScope(discovery))},
+		want: "Certificate,ConfigMap",
+	}, {
+		name: "first resolver wins",
+		opts: []NamespaceOption{ResolveScope(func(gvk schema.GroupVersionKind) (bool, bool) {
+			return gvk.Kind == "ConfigMap", gvk.Kind == "ConfigMap"
+		}, CRDScope(m))},
+		want: "Certificate",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := m.Filter(NoCRDs).Transform(InjectNamespace("ns", test.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			injected := []string{}
+			for _, u := range result.Resources() {
+				if u.GetNamespace() == "ns" {
+					injected = append(injected, u.GetKind())
+				}
+			}
+			if got := strings.Join(injected, ","); got != test.want {
+				t.Errorf("Injected namespace into %s, want %s", got, test.want)
+			}
+		})
+	}
+	// Once each for cert-manager.io/v1, v1 and rbac.authorization.k8s.io/v1
+	if discovery.calls != 3 {
+		t.Errorf("Expected discovery to be cached, got %d calls", discovery.calls)
+	}
+
+	// The manifest's CRDs and its client's RESTMapper are consulted
+	injected := func(m Manifest) string {
+		t.Helper()
+		result, err := m.Filter(NoCRDs).Transform(m.InjectNamespace("ns"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		kinds := []string{}
+		for _, u := range result.Resources() {
+			if u.GetNamespace() == "ns" {
+				kinds = append(kinds, u.GetKind())
+			}
+		}
+		return strings.Join(kinds, ",")
+	}
+	if got := injected(m); got != "Certificate,ConfigMap" {
+		t.Errorf("Injected namespace into %s, want Certificate,ConfigMap", got)
+	}
+	mapped, _ := ManifestFrom(Slice(m.Filter(NoCRDs).Resources()), UseClient(mappingClient{&fake.Client{}, mapper}))
+	if got := injected(mapped); got != "Certificate,ConfigMap" {
+		t.Errorf("Injected namespace into %s, want Certificate,ConfigMap", got)
+	}
+
+	// Failures aren't cached
+	resolve := DiscoveryScope(discovery)
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	if _, known := resolve(configMap); known {
+		t.Error("Expected ConfigMap to be unknown")
+	}
+	discovery.resources["v1"] = []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}}
+	if clusterScoped, known := resolve(configMap); clusterScoped || !known {
+		t.Errorf("Expected ConfigMap to be namespaced after a retry, got %v, %v", clusterScoped, known)
+	}
+}
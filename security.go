@@ -0,0 +1,259 @@
+package manifestival
+
+import (
+	"fmt"
+
+	"github.com/manifestival/manifestival/internal/workloads"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SecurityChange records a security context field set or removed by
+// HardenSecurity
+type SecurityChange struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Container is empty for the security context of the pod
+	Container string
+	// Field is the path within the container or pod spec, e.g.
+	// securityContext.runAsNonRoot
+	Field string
+	// Old is nil for a field that wasn't set, and New for one removed
+	Old interface{}
+	New interface{}
+}
+
+func (c SecurityChange) String() string {
+	target := fmt.Sprintf("%s %s", c.Kind, c.Name)
+	if c.Container != "" {
+		target += " container " + c.Container
+	}
+	return fmt.Sprintf("%s: %s %v -> %v", target, c.Field, c.Old, c.New)
+}
+
+// SecurityOption configures HardenSecurity
+type SecurityOption func(*securityOptions)
+
+type securityOptions struct {
+	override bool
+	writable map[string]bool
+	report   *[]SecurityChange
+}
+
+// OverrideInsecure replaces the existing values that violate the
+// restricted Pod Security Standard, e.g. privileged containers,
+// allowPrivilegeEscalation, a runAsUser of 0, an Unconfined seccomp
+// profile, or added capabilities other than NET_BIND_SERVICE. Without
+// it, only unset fields are filled in.
+var OverrideInsecure SecurityOption = func(o *securityOptions) {
+	o.override = true
+}
+
+// WritableRootFilesystem leaves readOnlyRootFilesystem unset for the
+// named containers, or all containers if none are named, for those
+// applications that must write outside of their volumes
+func WritableRootFilesystem(containers ...string) SecurityOption {
+	return func(o *securityOptions) {
+		if len(containers) == 0 {
+			o.writable[""] = true
+		}
+		for _, name := range containers {
+			o.writable[name] = true
+		}
+	}
+}
+
+// SecurityReport records every change made by HardenSecurity in
+// report, in the order of the resources and their containers. The
+// changes to a resource are recorded once it has been hardened, and
+// replace any recorded when it was hardened before, so that the report
+// describes the last Transform. It's incomplete if Transform fails.
+func SecurityReport(report *[]SecurityChange) SecurityOption {
+	return func(o *securityOptions) {
+		o.report = report
+	}
+}
+
+func newSecurityOptions(opts []SecurityOption) *securityOptions {
+	result := &securityOptions{writable: map[string]bool{}}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
+
+// HardenSecurity creates a Transformer which fills in the security
+// contexts of workloads to satisfy the restricted Pod Security
+// Standard, e.g. running as non-root with a read-only root filesystem.
+//
+// A privileged container, or one adding CAP_SYS_ADMIN, is left able
+// to escalate its privileges, which the API server would otherwise
+// reject, unless OverrideInsecure is passed.
+func HardenSecurity(opts ...SecurityOption) Transformer {
+	o := newSecurityOptions(opts)
+	return func(u *unstructured.Unstructured) error {
+		spec, ok := workloads.PodSpec(u)
+		if !ok {
+			return nil
+		}
+		h := &hardening{securityOptions: o, u: u}
+		if err := h.pod(spec); err != nil {
+			return fmt.Errorf("%s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		for _, container := range workloads.Containers(spec) {
+			if err := h.container(container); err != nil {
+				return fmt.Errorf("%s %s: container %v: %w", u.GetKind(), u.GetName(), container["name"], err)
+			}
+		}
+		h.publish()
+		return nil
+	}
+}
+
+// hardening applies the security options to a single resource
+type hardening struct {
+	*securityOptions
+	u       *unstructured.Unstructured
+	name    string
+	changes []SecurityChange
+}
+
+func (h *hardening) pod(spec map[string]interface{}) error {
+	sc, err := object(spec, "securityContext")
+	if err != nil {
+		return err
+	}
+	h.name = ""
+	if v, found := sc["runAsNonRoot"]; !found || (h.override && v != true) {
+		h.set(sc, "securityContext.", "runAsNonRoot", true)
+	}
+	h.common(sc)
+	if _, found := sc["seccompProfile"]; !found {
+		h.set(sc, "securityContext.", "seccompProfile", map[string]interface{}{"type": "RuntimeDefault"})
+	}
+	spec["securityContext"] = sc
+	return nil
+}
+
+func (h *hardening) container(container map[string]interface{}) error {
+	sc, err := object(container, "securityContext")
+	if err != nil {
+		return err
+	}
+	caps, err := object(sc, "capabilities")
+	if err != nil {
+		return err
+	}
+	name, _ := container["name"].(string)
+	h.name = name
+	if v, found := sc["runAsNonRoot"]; found && h.override && v != true {
+		h.set(sc, "securityContext.", "runAsNonRoot", true)
+	}
+	h.common(sc)
+	if h.override && sc["privileged"] == true {
+		h.set(sc, "securityContext.", "privileged", false)
+	}
+
+	drop, _ := caps["drop"].([]interface{})
+	if !contains(drop, "ALL") {
+		h.set(caps, "securityContext.capabilities.", "drop", append(drop, "ALL"))
+	}
+	add, _ := caps["add"].([]interface{})
+	if h.override && len(add) > 0 {
+		allowed := []interface{}{}
+		for _, c := range add {
+			if c == "NET_BIND_SERVICE" {
+				allowed = append(allowed, c)
+			}
+		}
+		switch {
+		case len(allowed) == 0:
+			h.set(caps, "securityContext.capabilities.", "add", nil)
+		case len(allowed) != len(add):
+			h.set(caps, "securityContext.capabilities.", "add", allowed)
+		}
+	}
+	sc["capabilities"] = caps
+
+	escalates := sc["privileged"] == true || contains(caps["add"], "SYS_ADMIN") || contains(caps["add"], "CAP_SYS_ADMIN")
+	if v, found := sc["allowPrivilegeEscalation"]; (!found && !escalates) || (h.override && v != false) {
+		h.set(sc, "securityContext.", "allowPrivilegeEscalation", false)
+	}
+	if _, found := sc["readOnlyRootFilesystem"]; !found && !h.writable[""] && !h.writable[name] {
+		h.set(sc, "securityContext.", "readOnlyRootFilesystem", true)
+	}
+	container["securityContext"] = sc
+	return nil
+}
+
+// common overrides the insecure fields shared by pod and container
+// security contexts
+func (h *hardening) common(sc map[string]interface{}) {
+	if !h.override {
+		return
+	}
+	if v, found := sc["runAsUser"]; found && fmt.Sprint(v) == "0" {
+		h.set(sc, "securityContext.", "runAsUser", nil)
+	}
+	if profile, _ := sc["seccompProfile"].(map[string]interface{}); profile != nil && profile["type"] == "Unconfined" {
+		h.set(sc, "securityContext.", "seccompProfile", map[string]interface{}{"type": "RuntimeDefault"})
+	}
+}
+
+// set sets, or removes if value is nil, the field of obj, recording
+// the change
+func (h *hardening) set(obj map[string]interface{}, prefix, field string, value interface{}) {
+	old := obj[field]
+	if value == nil {
+		delete(obj, field)
+	} else {
+		obj[field] = value
+	}
+	h.changes = append(h.changes, SecurityChange{
+		Kind:      h.u.GetKind(),
+		Name:      h.u.GetName(),
+		Namespace: h.u.GetNamespace(),
+		Container: h.name,
+		Field:     prefix + field,
+		Old:       old,
+		New:       value,
+	})
+}
+
+// publish replaces the changes in the report to the resource with
+// those just made
+func (h *hardening) publish() {
+	if h.report == nil {
+		return
+	}
+	result := []SecurityChange{}
+	for _, c := range *h.report {
+		if c.Kind != h.u.GetKind() || c.Name != h.u.GetName() || c.Namespace != h.u.GetNamespace() {
+			result = append(result, c)
+		}
+	}
+	*h.report = append(result, h.changes...)
+}
+
+// object returns the map in a field of obj, or a new one if unset
+func object(obj map[string]interface{}, field string) (map[string]interface{}, error) {
+	v, found := obj[field]
+	if !found || v == nil {
+		return map[string]interface{}{}, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not an object", field)
+	}
+	return m, nil
+}
+
+func contains(list interface{}, value string) bool {
+	l, _ := list.([]interface{})
+	for _, v := range l {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
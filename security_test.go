@@ -0,0 +1,200 @@
+package manifestival_test
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestHardenSecurity(t *testing.T) {
+	m, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = m.Transform(
+		SetField(".spec.template.spec.containers[1].securityContext", map[string]interface{}{
+			"privileged": true,
+			"capabilities": map[string]interface{}{
+				"add": []interface{}{"SYS_ADMIN", "NET_BIND_SERVICE"},
+			},
+		}, ByKind("Deployment")),
+		SetField(".spec.template.spec.securityContext", map[string]interface{}{
+			"runAsNonRoot": false,
+			"runAsUser":    int64(0),
+		}, ByKind("StatefulSet")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restricted := map[string]interface{}{
+		"allowPrivilegeEscalation": false,
+		"capabilities":             map[string]interface{}{"drop": []interface{}{"ALL"}},
+		"readOnlyRootFilesystem":   true,
+	}
+	var report []SecurityChange
+	hardened, err := m.Transform(HardenSecurity(SecurityReport(&report), WritableRootFilesystem("db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		kind      string
+		container string
+		want      interface{}
+	}{{
+		kind:      "Deployment",
+		container: "app",
+		want:      restricted,
+	}, {
+		kind:      "Deployment",
+		container: "sidecar",
+		want: map[string]interface{}{
+			"privileged": true,
+			"capabilities": map[string]interface{}{
+				"add":  []interface{}{"SYS_ADMIN", "NET_BIND_SERVICE"},
+				"drop": []interface{}{"ALL"},
+			},
+			"readOnlyRootFilesystem": true,
+		},
+	}, {
+		kind:      "StatefulSet",
+		container: "db",
+		want: map[string]interface{}{
+			"allowPrivilegeEscalation": false,
+			"capabilities":             map[string]interface{}{"drop": []interface{}{"ALL"}},
+		},
+	}, {
+		kind:      "Pod",
+		container: "debug",
+		want:      restricted,
+	}, {
+		kind:      "CronJob",
+		container: "backup",
+		want:      restricted,
+	}}
+	for _, test := range tests {
+		u := hardened.Filter(ByKind(test.kind)).Resources()[0]
+		if got := containerSecurity(t, &u, test.container); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %s securityContext = %v, want %v", test.kind, test.container, got, test.want)
+		}
+	}
+	u := hardened.Filter(ByKind("StatefulSet")).Resources()[0]
+	want := map[string]interface{}{
+		"runAsNonRoot":   false,
+		"runAsUser":      int64(0),
+		"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+	}
+	if got := podSpec(t, &u)["securityContext"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("StatefulSet securityContext = %v, want %v", got, want)
+	}
+	if hardened.Filter(ByKind("ConfigMap")).Resources()[0].Object["spec"] != nil {
+		t.Error("Expected ConfigMap to be unchanged")
+	}
+
+	// Every change is reported
+	assert(t, containsChange(report, SecurityChange{
+		Kind:      "Deployment",
+		Name:      "deployment",
+		Container: "app",
+		Field:     "securityContext.allowPrivilegeEscalation",
+		New:       false,
+	}), true)
+	assert(t, containsChange(report, SecurityChange{
+		Kind:  "Pod",
+		Name:  "pod",
+		Field: "securityContext.runAsNonRoot",
+		New:   true,
+	}), true)
+	assert(t, containsChange(report, SecurityChange{
+		Kind:      "Pod",
+		Name:      "pod",
+		Container: "init",
+		Field:     "securityContext.readOnlyRootFilesystem",
+		New:       true,
+	}), true)
+
+	// Hardening again replaces the changes reported
+	changes := len(report)
+	if _, err := m.Transform(HardenSecurity(SecurityReport(&report), WritableRootFilesystem("db"))); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, len(report), changes)
+
+	// Hardening is idempotent
+	report = nil
+	if _, err := hardened.Transform(HardenSecurity(SecurityReport(&report), WritableRootFilesystem("db"))); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, len(report), 0)
+
+	// Insecure values are replaced
+	report = nil
+	overridden, err := m.Transform(HardenSecurity(OverrideInsecure, WritableRootFilesystem(), SecurityReport(&report)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u = overridden.Filter(ByKind("Deployment")).Resources()[0]
+	want = map[string]interface{}{
+		"privileged":               false,
+		"allowPrivilegeEscalation": false,
+		"capabilities": map[string]interface{}{
+			"add":  []interface{}{"NET_BIND_SERVICE"},
+			"drop": []interface{}{"ALL"},
+		},
+	}
+	if got := containerSecurity(t, &u, "sidecar"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sidecar securityContext = %v, want %v", got, want)
+	}
+	u = overridden.Filter(ByKind("StatefulSet")).Resources()[0]
+	want = map[string]interface{}{
+		"runAsNonRoot":   true,
+		"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+	}
+	if got := podSpec(t, &u)["securityContext"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("StatefulSet securityContext = %v, want %v", got, want)
+	}
+	assert(t, containsChange(report, SecurityChange{
+		Kind:  "StatefulSet",
+		Name:  "statefulset",
+		Field: "securityContext.runAsUser",
+		Old:   int64(0),
+	}), true)
+
+	invalid, _ := m.Transform(SetField(".spec.template.spec.containers[0].securityContext", "root", ByKind("DaemonSet")))
+	report = nil
+	if _, err := invalid.Transform(HardenSecurity(SecurityReport(&report))); err == nil {
+		t.Error("Expected an error for an invalid securityContext")
+	}
+	for _, c := range report {
+		if c.Kind == "DaemonSet" {
+			t.Errorf("Expected no changes reported for the failed DaemonSet, got %v", c)
+		}
+	}
+}
+
+func containerSecurity(t *testing.T, u *unstructured.Unstructured, name string) interface{} {
+	t.Helper()
+	spec := podSpec(t, u)
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _, _ := unstructured.NestedSlice(spec, field)
+		for _, c := range containers {
+			if c := c.(map[string]interface{}); c["name"] == name {
+				return c["securityContext"]
+			}
+		}
+	}
+	t.Fatalf("%s %s has no container %s", u.GetKind(), u.GetName(), name)
+	return nil
+}
+
+func containsChange(report []SecurityChange, change SecurityChange) bool {
+	for _, c := range report {
+		if reflect.DeepEqual(c, change) {
+			return true
+		}
+	}
+	return false
+}
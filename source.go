@@ -3,8 +3,10 @@ package manifestival
 import (
 	"io"
 
+	"github.com/manifestival/manifestival/internal/helm"
 	"github.com/manifestival/manifestival/internal/sources"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
 )
 
 // Source is the interface through which all Manifests are created.
@@ -12,8 +14,17 @@ type Source interface {
 	Parse() ([]unstructured.Unstructured, error)
 }
 
+// RawSource is a Source whose unparsed content, one element per file
+// or document stream, is available for preprocessing by Sources like
+// Template.
+type RawSource interface {
+	Source
+	Raw() ([][]byte, error)
+}
+
 // Path is a Source represented as a comma-delimited list of files,
-// directories, and URL's
+// directories, and URL's, any of which may be tar, gzipped tar or zip
+// archives, identified by their .tar, .tar.gz, .tgz or .zip extension.
 type Path string
 
 // Recursive is identical to Path, but dirs are searched recursively
@@ -27,19 +38,70 @@ func Reader(r io.Reader) Source {
 	return reader{r}
 }
 
+// Archive is a Source comprised of the YAML and JSON files within a
+// tar, gzipped tar or zip archive read from r. If any patterns, as
+// understood by path.Match, are given, only the files whose paths, or
+// any of their parent directories, match one of them are parsed.
+// Archives referenced by Path are detected by their file extension.
+func Archive(r io.Reader, patterns ...string) Source {
+	return archive{r, patterns}
+}
+
+// Template renders the content of src as a Go text/template with
+// values as its root object, e.g. `{{ .image | default "nginx" }}`,
+// before parsing it as YAML. The available functions are limited to
+// those without side effects: default, empty, coalesce, required,
+// quote, squote, toYaml, fromYaml, toJson, indent, nindent, trim,
+// upper, lower, replace, contains, list and dict.
+func Template(src Source, values map[string]interface{}) Source {
+	return templated{src, values}
+}
+
+// HelmChart is a Source that renders a local Helm chart, either a
+// directory or a .tgz archive, much like `helm template` would, but
+// without access to a cluster or any chart repository. Dependencies
+// must therefore be present in the chart's charts/ directory, and
+// .Capabilities is stubbed from KubeVersion and APIVersions, the
+// latter supplementing the types known to client-go's scheme.
+type HelmChart struct {
+	Path        string
+	Values      map[string]interface{}
+	ReleaseName string // defaults to "release-name"
+	Namespace   string // defaults to "default"
+	KubeVersion string // defaults to "v1.27.0"
+	APIVersions []string
+}
+
 var _ Source = Path("")
 var _ Source = Recursive("")
 var _ Source = Slice([]unstructured.Unstructured{})
-var _ Source = reader{} // see Reader(io.Reader)
+var _ Source = reader{}    // see Reader(io.Reader)
+var _ Source = archive{}   // see Archive(io.Reader, ...string)
+var _ Source = templated{} // see Template(Source, map[string]interface{})
+var _ Source = HelmChart{}
+
+var _ RawSource = Path("")
+var _ RawSource = Recursive("")
+var _ RawSource = reader{}
+var _ RawSource = archive{}
+var _ RawSource = templated{}
 
 func (p Path) Parse() ([]unstructured.Unstructured, error) {
 	return sources.Parse(string(p), false)
 }
 
+func (p Path) Raw() ([][]byte, error) {
+	return sources.Read(string(p), false, nil)
+}
+
 func (r Recursive) Parse() ([]unstructured.Unstructured, error) {
 	return sources.Parse(string(r), true)
 }
 
+func (r Recursive) Raw() ([][]byte, error) {
+	return sources.Read(string(r), true, nil)
+}
+
 func (s Slice) Parse() ([]unstructured.Unstructured, error) {
 	return []unstructured.Unstructured(s), nil
 }
@@ -48,6 +110,93 @@ func (r reader) Parse() ([]unstructured.Unstructured, error) {
 	return sources.Decode(r.real)
 }
 
+func (r reader) Raw() ([][]byte, error) {
+	data, err := io.ReadAll(r.real)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{data}, nil
+}
+
+func (a archive) Parse() ([]unstructured.Unstructured, error) {
+	docs, err := a.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return sources.DecodeAll(docs)
+}
+
+func (a archive) Raw() ([][]byte, error) {
+	data, err := io.ReadAll(a.real)
+	if err != nil {
+		return nil, err
+	}
+	return sources.ReadArchive(data, a.patterns...)
+}
+
+func (t templated) Parse() ([]unstructured.Unstructured, error) {
+	docs, err := t.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return sources.DecodeAll(docs)
+}
+
+func (t templated) Raw() ([][]byte, error) {
+	docs, err := raw(t.src)
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		if docs[i], err = sources.Render("manifest", doc, t.values); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func (h HelmChart) Parse() ([]unstructured.Unstructured, error) {
+	chart, err := helm.Load(h.Path)
+	if err != nil {
+		return nil, err
+	}
+	return helm.Render(chart, h.Values, helm.Options{
+		ReleaseName: h.ReleaseName,
+		Namespace:   h.Namespace,
+		KubeVersion: h.KubeVersion,
+		APIVersions: h.APIVersions,
+	})
+}
+
 type reader struct {
 	real io.Reader
 }
+
+type archive struct {
+	real     io.Reader
+	patterns []string
+}
+
+type templated struct {
+	src    Source
+	values map[string]interface{}
+}
+
+// raw returns the unparsed content of src, or its resources
+// marshalled as YAML if it's not a RawSource
+func raw(src Source) ([][]byte, error) {
+	if r, ok := src.(RawSource); ok {
+		return r.Raw()
+	}
+	resources, err := src.Parse()
+	if err != nil {
+		return nil, err
+	}
+	docs := make([][]byte, len(resources))
+	for i, u := range resources {
+		if docs[i], err = yaml.Marshal(u.Object); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
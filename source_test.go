@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
 	. "github.com/manifestival/manifestival"
 )
 
@@ -55,3 +59,105 @@ spec:
 		})
 	}
 }
+
+func TestTemplate(t *testing.T) {
+	src := Reader(strings.NewReader(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+  labels:{{ .labels | toYaml | nindent 4 }}
+spec:
+  replicas: {{ .replicas | default 1 }}
+`))
+	values := map[string]interface{}{
+		"name":   "web",
+		"labels": map[string]interface{}{"app": "web"},
+	}
+	m, err := ManifestFrom(Template(src, values))
+	if err != nil {
+		t.Fatalf("Template returned: %v", err)
+	}
+	u := m.Resources()[0]
+	if u.GetName() != "web" {
+		t.Errorf("Expected name 'web', got %q", u.GetName())
+	}
+	if u.GetLabels()["app"] != "web" {
+		t.Errorf("Expected label app=web, got %v", u.GetLabels())
+	}
+	if replicas, _, _ := unstructured.NestedInt64(u.Object, "spec", "replicas"); replicas != 1 {
+		t.Errorf("Expected default replicas of 1, got %d", replicas)
+	}
+}
+
+func TestTemplateSlice(t *testing.T) {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetName("{{ .name }}")
+	m, err := ManifestFrom(Template(Slice([]unstructured.Unstructured{u}), map[string]interface{}{"name": "foo"}))
+	if err != nil {
+		t.Fatalf("Template returned: %v", err)
+	}
+	if name := m.Resources()[0].GetName(); name != "foo" {
+		t.Errorf("Expected name 'foo', got %q", name)
+	}
+}
+
+func TestTemplateError(t *testing.T) {
+	src := Reader(strings.NewReader("name: {{ required \"name is required\" .name }}"))
+	if _, err := ManifestFrom(Template(src, nil)); err == nil {
+		t.Error("Expected an error for a missing required value")
+	}
+}
+
+func TestHelmChart(t *testing.T) {
+	src := HelmChart{
+		Path:        "internal/helm/testdata/mychart",
+		Values:      map[string]interface{}{"image": map[string]interface{}{"tag": "1.25"}},
+		ReleaseName: "web",
+		Namespace:   "prod",
+	}
+	m, err := ManifestFrom(src)
+	if err != nil {
+		t.Fatalf("HelmChart returned: %v", err)
+	}
+	deployments := m.Filter(ByKind("Deployment")).Resources()
+	if len(deployments) != 1 {
+		t.Fatalf("Expected a single Deployment, got %v", m.Resources())
+	}
+	if name := deployments[0].GetName(); name != "web-mychart" {
+		t.Errorf("Expected name 'web-mychart', got %q", name)
+	}
+	if ns := deployments[0].GetNamespace(); ns != "prod" {
+		t.Errorf("Expected namespace 'prod', got %q", ns)
+	}
+	if _, err := ManifestFrom(HelmChart{Path: "internal/helm/testdata/missing"}); err == nil {
+		t.Error("Expected an error for a missing chart")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	f, err := os.Open("internal/sources/testdata/tree.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := ManifestFrom(Archive(f, "dir/*"))
+	if err != nil {
+		t.Fatalf("Archive returned: %v", err)
+	}
+	if len(m.Resources()) != 3 {
+		t.Errorf("Expected 3 resources from dir/, got %v", m.Resources())
+	}
+	m, err = ManifestFrom(Path("internal/sources/testdata/tree.tar.gz"))
+	if err != nil {
+		t.Fatalf("Path returned: %v", err)
+	}
+	if len(m.Resources()) != 5 {
+		t.Errorf("Expected 5 resources, got %v", m.Resources())
+	}
+	if _, err := ManifestFrom(Archive(strings.NewReader("not an archive"))); err == nil {
+		t.Error("Expected an error for invalid archive")
+	}
+}
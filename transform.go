@@ -4,6 +4,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/manifestival/manifestival/internal/sources"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -38,11 +40,54 @@ func (m Manifest) Transform(fns ...Transformer) (Manifest, error) {
 	return result, nil
 }
 
+// When returns a Transformer that applies t only to the resources for
+// which pred returns true, e.g. When(Not(ByLabel("app", "web")), t)
+func When(pred Predicate, t Transformer) Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if t == nil || !pred(u) {
+			return nil
+		}
+		return t(u)
+	}
+}
+
+// Chain returns a Transformer that applies each of the passed
+// Transformers in order, stopping at the first error, so that a
+// sequence may be passed to When
+func Chain(fns ...Transformer) Transformer {
+	return func(u *unstructured.Unstructured) error {
+		for _, transform := range fns {
+			if transform == nil {
+				continue
+			}
+			if err := transform(u); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ForKinds returns a Transformer that applies t only to resources of
+// the passed kinds
+func ForKinds(t Transformer, kinds ...string) Transformer {
+	preds := make([]Predicate, len(kinds))
+	for i, kind := range kinds {
+		preds[i] = ByKind(kind)
+	}
+	return When(Any(preds...), t)
+}
+
 // InjectNamespace creates a Transformer which adds a namespace to existing
 // resources if appropriate. We assume all resources in the manifest live in
-// the same namespace.
-func InjectNamespace(ns string) Transformer {
+// the same namespace. Cluster-scoped resources are identified by a list of
+// well-known kinds, unless the options provide a better way, e.g. the
+// CRDScope of the manifest or the DiscoveryScope of a cluster. Unlike
+// the Manifest method of the same name, it doesn't consult the CRDs in
+// the manifest being transformed.
+func InjectNamespace(ns string, opts ...NamespaceOption) Transformer {
 	namespace := resolveEnv(ns)
+	o := newNamespaceOptions(opts)
 	updateService := func(obj map[string]interface{}, fields ...string) error {
 		srv, found, err := unstructured.NestedFieldNoCopy(obj, fields...)
 		if err != nil {
@@ -57,7 +102,7 @@ func InjectNamespace(ns string) Transformer {
 		return nil
 	}
 	return func(u *unstructured.Unstructured) error {
-		if !isClusterScoped(u.GetKind()) {
+		if !o.isClusterScoped(u.GroupVersionKind()) {
 			u.SetNamespace(namespace)
 		}
 		switch strings.ToLower(u.GetKind()) {
@@ -90,17 +135,40 @@ func InjectNamespace(ns string) Transformer {
 	}
 }
 
+// InjectNamespace is like the function of the same name, but also
+// resolves the scope of kinds from the CRDs in m and, if m's Client
+// has one, its RESTMapper
+func (m Manifest) InjectNamespace(ns string, opts ...NamespaceOption) Transformer {
+	resolvers := []ScopeResolver{CRDScope(m)}
+	if c, ok := m.Client.(interface{ RESTMapper() meta.RESTMapper }); ok {
+		resolvers = append(resolvers, RESTMapperScope(c.RESTMapper()))
+	}
+	return InjectNamespace(ns, append(opts, ResolveScope(resolvers...))...)
+}
+
 // InjectOwner creates a Transformer which adds an OwnerReference
-// pointing to `owner`
-func InjectOwner(owner Owner) Transformer {
+// pointing to `owner`. By default, it replaces any existing
+// references with one to the owner as controller, but the options
+// allow it to be appended, its flags to be set, and resources it
+// can't own to be skipped.
+func InjectOwner(owner Owner, opts ...OwnerOption) Transformer {
+	o := newOwnerOptions(opts)
 	return func(u *unstructured.Unstructured) error {
-		u.SetOwnerReferences([]v1.OwnerReference{*v1.NewControllerRef(owner, owner.GroupVersionKind())})
+		if o.skip(owner, u) {
+			return nil
+		}
+		refs, err := o.references(u, o.reference(owner))
+		if err != nil {
+			return err
+		}
+		u.SetOwnerReferences(refs)
 		return nil
 	}
 }
 
+// isClusterScoped returns true for well-known cluster-scoped kinds,
+// consulted when InjectNamespace is given no better way of knowing
 func isClusterScoped(kind string) bool {
-	// TODO: something more clever using !APIResource.Namespaced maybe?
 	switch strings.ToLower(kind) {
 	case "componentstatus",
 		"namespace",
@@ -128,6 +196,10 @@ func isClusterScoped(kind string) bool {
 }
 
 func resolveEnv(x string) string {
+	if strings.Contains(x, "${") {
+		result, _ := sources.ExpandEnv(x, os.LookupEnv, false)
+		return result
+	}
 	if len(x) > 1 && x[:1] == "$" {
 		return os.Getenv(x[1:])
 	}
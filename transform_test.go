@@ -1,6 +1,7 @@
 package manifestival_test
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
@@ -69,6 +70,56 @@ func TestTransformCombo(t *testing.T) {
 	}
 }
 
+func TestTransformCombinators(t *testing.T) {
+	f, err := ManifestFrom(Path("testdata/workloads/workloads.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := func(v string) Transformer {
+		return func(u *unstructured.Unstructured) error {
+			u.SetResourceVersion(u.GetResourceVersion() + v)
+			return nil
+		}
+	}
+	f, err = f.Transform(
+		When(ByName("deployment"), Chain(version("1"), nil, version("2"))),
+		ForKinds(version("3"), "Job", "CronJob"),
+		When(Not(Any(ByKind("Pod"), ByKind("ConfigMap"))), version("4")),
+		When(Everything, nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"Pod":         "",
+		"Deployment":  "124",
+		"StatefulSet": "4",
+		"DaemonSet":   "4",
+		"ReplicaSet":  "4",
+		"Job":         "34",
+		"CronJob":     "34",
+		"ConfigMap":   "",
+	}
+	for _, u := range f.Resources() {
+		if got := u.GetResourceVersion(); got != want[u.GetKind()] {
+			t.Errorf("%s resourceVersion = %q, want %q", u.GetKind(), got, want[u.GetKind()])
+		}
+	}
+
+	failing := func(u *unstructured.Unstructured) error {
+		return fmt.Errorf("failed %s", u.GetName())
+	}
+	if _, err := f.Transform(Chain(failing, version("5"))); err == nil {
+		t.Error("Expected an error from the chain")
+	}
+	if _, err := f.Transform(ForKinds(failing, "Deployment")); err == nil {
+		t.Error("Expected an error for the deployment")
+	}
+	if _, err := f.Transform(ForKinds(failing)); err != nil {
+		t.Errorf("Expected no kinds to match, got %v", err)
+	}
+}
+
 func TestInjectNamespace(t *testing.T) {
 	assert := func(u unstructured.Unstructured, expected string) {
 		v, _, _ := unstructured.NestedSlice(u.Object, "subjects")
@@ -248,3 +299,15 @@ func TestInjectOwnerOfClusterType(t *testing.T) {
 	m, _ = m.Transform(InjectOwner(&ns))
 	assert(t, len(m.Resources()[0].GetOwnerReferences()), 1)
 }
+
+func TestInjectNamespaceBraces(t *testing.T) {
+	os.Unsetenv("UNSET_NS")
+	f, _ := NewManifest("testdata/crb.yaml")
+	f, err := f.Transform(InjectNamespace("${UNSET_NS:-fallback}"))
+	if err != nil {
+		t.Error(err)
+	}
+	if f.Resources()[0].GetName() != "fallback" {
+		t.Errorf("Expected namespace name to be fallback, got %s", f.Resources()[0].GetName())
+	}
+}
@@ -0,0 +1,160 @@
+package manifestival
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/manifestival/manifestival/internal/sources"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// URLOption configures how the URL Source, and Path and Recursive
+// Sources created with With, fetch URLs. Regardless of options, any
+// response without a 2xx status is an error.
+type URLOption func(*sources.Fetcher)
+
+// HTTPClient overrides http.DefaultClient, e.g. to configure TLS or
+// a proxy
+func HTTPClient(client *http.Client) URLOption {
+	return func(f *sources.Fetcher) {
+		f.Client = client
+	}
+}
+
+// HTTPHeader adds a header to each request
+func HTTPHeader(key, value string) URLOption {
+	return func(f *sources.Fetcher) {
+		if f.Header == nil {
+			f.Header = http.Header{}
+		}
+		f.Header.Add(key, value)
+	}
+}
+
+// BearerToken sets the Authorization header of each request
+func BearerToken(token string) URLOption {
+	return func(f *sources.Fetcher) {
+		if f.Header == nil {
+			f.Header = http.Header{}
+		}
+		f.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// Timeout limits the duration of each request, including reading the
+// response body
+func Timeout(d time.Duration) URLOption {
+	return func(f *sources.Fetcher) {
+		f.Timeout = d
+	}
+}
+
+// FetchContext makes each request with ctx, allowing it to be canceled
+func FetchContext(ctx context.Context) URLOption {
+	return func(f *sources.Fetcher) {
+		f.Context = ctx
+	}
+}
+
+// MaxBytes fails any request whose response body exceeds n bytes
+func MaxBytes(n int64) URLOption {
+	return func(f *sources.Fetcher) {
+		f.MaxBytes = n
+	}
+}
+
+// SHA256 fails the fetched URL if its content lacks the given hex
+// digest, and can't be used with a Path of more than one URL. A URL
+// may also declare its own digest with a fragment, e.g.
+// `https://site.com/release.yaml#sha256=<digest>`.
+func SHA256(digest string) URLOption {
+	return func(f *sources.Fetcher) {
+		f.SHA256 = digest
+	}
+}
+
+// Checksums verifies each fetched URL against the digest listed for
+// its file name in the file at location, which may be relative to the
+// fetched URL, e.g. "SHA256SUMS". Both the `sha256sum` and BSD-style
+// formats are supported.
+func Checksums(location string) URLOption {
+	return func(f *sources.Fetcher) {
+		f.Checksums = location
+	}
+}
+
+// Cache stores fetched content in dir, serving it without a request
+// for the duration of ttl, and thereafter revalidating it using its
+// ETag or Last-Modified header. Cached content of any age is served
+// when the server can't be reached.
+func Cache(dir string, ttl time.Duration) URLOption {
+	return func(f *sources.Fetcher) {
+		f.Cache = &sources.Cache{Dir: dir, TTL: ttl}
+	}
+}
+
+// URL is a Source comprised of the manifests fetched from a single
+// URL, which may be a tar, gzipped tar or zip archive identified by
+// its extension
+func URL(url string, opts ...URLOption) Source {
+	return remote{url, newFetcher(opts)}
+}
+
+// With returns a Source that fetches any URL's in p as configured by
+// opts
+func (p Path) With(opts ...URLOption) Source {
+	return fetchedPath{string(p), false, newFetcher(opts)}
+}
+
+// With returns a Source that fetches any URL's in r as configured by
+// opts
+func (r Recursive) With(opts ...URLOption) Source {
+	return fetchedPath{string(r), true, newFetcher(opts)}
+}
+
+var _ RawSource = remote{}      // see URL(string, ...URLOption)
+var _ RawSource = fetchedPath{} // see Path.With(...URLOption)
+
+type remote struct {
+	url     string
+	fetcher *sources.Fetcher
+}
+
+type fetchedPath struct {
+	pathname  string
+	recursive bool
+	fetcher   *sources.Fetcher
+}
+
+func newFetcher(opts []URLOption) *sources.Fetcher {
+	result := &sources.Fetcher{}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
+
+func (r remote) Parse() ([]unstructured.Unstructured, error) {
+	docs, err := r.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return sources.DecodeAll(docs)
+}
+
+func (r remote) Raw() ([][]byte, error) {
+	return sources.ReadURL(r.url, r.fetcher)
+}
+
+func (p fetchedPath) Parse() ([]unstructured.Unstructured, error) {
+	docs, err := p.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return sources.DecodeAll(docs)
+}
+
+func (p fetchedPath) Raw() ([][]byte, error) {
+	return sources.Read(p.pathname, p.recursive, p.fetcher)
+}
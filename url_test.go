@@ -0,0 +1,117 @@
+package manifestival_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/manifestival/manifestival"
+)
+
+func TestURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/manifest.yaml":
+			w.Write([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n"))
+		case "/tree.tar.gz":
+			http.ServeFile(w, r, "internal/sources/testdata/tree.tar.gz")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	auth := []URLOption{BearerToken("secret"), HTTPHeader("X-Tenant", "acme")}
+
+	tests := []struct {
+		name      string
+		source    Source
+		want      int
+		wantError bool
+	}{{
+		name:   "manifest",
+		source: URL(server.URL+"/manifest.yaml", auth...),
+		want:   1,
+	}, {
+		name:   "archive",
+		source: URL(server.URL+"/tree.tar.gz", auth...),
+		want:   5,
+	}, {
+		name:      "forbidden",
+		source:    URL(server.URL + "/manifest.yaml"),
+		wantError: true,
+	}, {
+		name:      "not found",
+		source:    URL(server.URL+"/missing.yaml", auth...),
+		wantError: true,
+	}, {
+		name:      "too large",
+		source:    URL(server.URL+"/manifest.yaml", append(auth, MaxBytes(10))...),
+		wantError: true,
+	}, {
+		name:      "checksum mismatch",
+		source:    URL(server.URL+"/manifest.yaml", append(auth, SHA256(strings.Repeat("0", 64)))...),
+		wantError: true,
+	}, {
+		name:      "fragment checksum mismatch",
+		source:    Path(server.URL + "/manifest.yaml#sha256=" + strings.Repeat("0", 64)).With(auth...),
+		wantError: true,
+	}, {
+		name:   "path",
+		source: Path(server.URL + "/manifest.yaml,testdata/tree/file.yaml").With(auth...),
+		want:   3,
+	}, {
+		name:   "recursive",
+		source: Recursive(server.URL + "/manifest.yaml,testdata/tree").With(auth...),
+		want:   6,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := ManifestFrom(test.source)
+			if test.wantError {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Resources()) != test.want {
+				t.Errorf("Got %d resources, wanted %d", len(m.Resources()), test.want)
+			}
+		})
+	}
+}
+
+func TestURLFromPathWithoutOptions(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	if _, err := ManifestFrom(Path(server.URL + "/missing.yaml")); err == nil || os.IsNotExist(err) {
+		t.Error("Expected a 404 error, got", err)
+	}
+}
+
+func TestURLCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n"))
+	}))
+	source := URL(server.URL+"/manifest.yaml", Cache(t.TempDir(), 0))
+	if _, err := ManifestFrom(source); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	m, err := ManifestFrom(source)
+	if err != nil {
+		t.Fatal("Expected cached content when offline, got", err)
+	}
+	if len(m.Resources()) != 1 || m.Resources()[0].GetName() != "foo" {
+		t.Error("Unexpected resources", m.Resources())
+	}
+}
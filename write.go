@@ -0,0 +1,195 @@
+package manifestival
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// leadingFields are written before all others, which are sorted
+var leadingFields = []string{"apiVersion", "kind", "metadata"}
+
+// WriteYAML writes the resources in the Manifest to w as a stream of
+// YAML documents, in the order they appear in the Manifest. The
+// apiVersion, kind and metadata fields of each resource come first,
+// followed by the rest in alphabetical order, as are all nested
+// fields.
+func (m Manifest) WriteYAML(w io.Writer) error {
+	for i := range m.resources {
+		doc, err := marshalYAML(&m.resources[i])
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the resources in the Manifest to w as a stream of
+// indented JSON objects, ordered as they are by WriteYAML
+func (m Manifest) WriteJSON(w io.Writer) error {
+	for i := range m.resources {
+		doc, err := marshalJSON(&m.resources[i])
+		if err != nil {
+			return err
+		}
+		if err := writeIndented(w, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteList writes the resources in the Manifest to w as the items
+// of a single v1 List in indented JSON, e.g. for `kubectl apply -f`
+func (m Manifest) WriteList(w io.Writer) error {
+	buf := bytes.NewBufferString(`{"apiVersion":"v1","kind":"List","metadata":{},"items":[`)
+	for i := range m.resources {
+		doc, err := marshalJSON(&m.resources[i])
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(doc)
+	}
+	buf.WriteString("]}")
+	return writeIndented(w, buf.Bytes())
+}
+
+// WriteDir writes each resource in the Manifest to its own YAML file
+// beneath dir at NAMESPACE/KIND[.GROUP]/NAME.yaml, lowercasing the
+// kind and group, e.g. default/deployment.apps/web.yaml. Resources
+// without a namespace are written beneath the _cluster directory. An
+// error is returned if two resources would be written to the same
+// file, or if its namespace, kind or name isn't a single path
+// segment, e.g. ../web.
+func (m Manifest) WriteDir(dir string) error {
+	root := filepath.Clean(dir)
+	written := map[string]bool{}
+	for i := range m.resources {
+		u := &m.resources[i]
+		path, err := resourcePath(u)
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(root, path)
+		if rel, err := filepath.Rel(root, name); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s %s would be written outside of %s", u.GetKind(), u.GetName(), dir)
+		}
+		if written[name] {
+			return fmt.Errorf("more than one resource would be written to %s", name)
+		}
+		written[name] = true
+		doc, err := marshalYAML(u)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, doc, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourcePath returns the relative path to which WriteDir writes u
+func resourcePath(u *unstructured.Unstructured) (string, error) {
+	namespace := u.GetNamespace()
+	if namespace == "" {
+		namespace = "_cluster"
+	}
+	kind := strings.ToLower(u.GetKind())
+	if group := u.GroupVersionKind().Group; group != "" {
+		kind += "." + strings.ToLower(group)
+	}
+	for _, segment := range []string{namespace, kind, u.GetName()} {
+		if !isPathSegment(segment) {
+			return "", fmt.Errorf("%s %s: invalid path segment %q", u.GetKind(), u.GetName(), segment)
+		}
+	}
+	return filepath.Join(namespace, kind, u.GetName()+".yaml"), nil
+}
+
+// isPathSegment returns true if s names a file within a directory
+func isPathSegment(s string) bool {
+	return s != "" && s != "." && !strings.Contains(s, "..") &&
+		!strings.ContainsAny(s, `/\`) && !filepath.IsAbs(s) && filepath.Base(s) == s
+}
+
+// fields returns the top-level fields of u in the order they're written
+func fields(u *unstructured.Unstructured) []string {
+	result := []string{}
+	for _, k := range leadingFields {
+		if _, ok := u.Object[k]; ok {
+			result = append(result, k)
+		}
+	}
+	rest := []string{}
+	for k := range u.Object {
+		switch k {
+		case "apiVersion", "kind", "metadata":
+		default:
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(result, rest...)
+}
+
+func marshalYAML(u *unstructured.Unstructured) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, k := range fields(u) {
+		doc, err := yaml.Marshal(map[string]interface{}{k: u.Object[k]})
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(doc)
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalJSON(u *unstructured.Unstructured) ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, k := range fields(u) {
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(u.Object[k])
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func writeIndented(w io.Writer, doc []byte) error {
+	buf := &bytes.Buffer{}
+	if err := json.Indent(buf, doc, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(w)
+	return err
+}
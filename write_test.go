@@ -0,0 +1,169 @@
+package manifestival_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/manifestival/manifestival"
+)
+
+const unordered = `
+spec:
+  replicas: 1
+  selector: {matchLabels: {app: web}}
+metadata: {namespace: default, name: web}
+kind: Deployment
+apiVersion: apps/v1
+---
+metadata: {name: config, namespace: default}
+data: {b: "2", a: "1"}
+apiVersion: v1
+kind: ConfigMap
+---
+kind: Namespace
+apiVersion: v1
+metadata: {name: default}
+`
+
+func TestWriteYAML(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(unordered)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := m.WriteYAML(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+data:
+  a: "1"
+  b: "2"
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: default
+`
+	if buf.String() != want {
+		t.Errorf("WriteYAML() =\n%s\nwant\n%s", buf, want)
+	}
+	roundTrip, err := ManifestFrom(Reader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roundTrip.Resources(), m.Resources()) {
+		t.Error("Expected identical resources after a round trip")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(unordered)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := m.WriteJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "{\n  \"apiVersion\": \"apps/v1\",\n  \"kind\": \"Deployment\",\n  \"metadata\": {") {
+		t.Errorf("Unexpected field order:\n%s", buf)
+	}
+	decoder := json.NewDecoder(buf)
+	for _, want := range m.Resources() {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+		got := unstructured.Unstructured{}
+		if err := got.UnmarshalJSON(doc); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWriteList(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(unordered)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := m.WriteList(buf); err != nil {
+		t.Fatal(err)
+	}
+	list := &unstructured.UnstructuredList{}
+	if err := list.UnmarshalJSON(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if list.GetKind() != "List" || list.GetAPIVersion() != "v1" {
+		t.Errorf("Unexpected list: %s", buf)
+	}
+	if !reflect.DeepEqual(list.Items, m.Resources()) {
+		t.Errorf("List items differ from the manifest:\n%s", buf)
+	}
+}
+
+func TestWriteDir(t *testing.T) {
+	m, err := ManifestFrom(Reader(strings.NewReader(unordered)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := m.WriteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"default/deployment.apps/web.yaml", "default/configmap/config.yaml", "_cluster/namespace/default.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+	roundTrip, err := ManifestFrom(Recursive(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roundTrip.Resources()) != 3 {
+		t.Errorf("Expected 3 resources, got %d", len(roundTrip.Resources()))
+	}
+	if err := m.Append(m).WriteDir(t.TempDir()); err == nil {
+		t.Error("Expected an error writing duplicate resources")
+	}
+	// Names that aren't a single path segment are rejected
+	for _, name := range []string{"../../../escaped", "..", "a/b", `a\b`} {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "a", "b", "c")
+		u := m.Resources()[1]
+		u.SetName(name)
+		bad, _ := ManifestFrom(Slice{u})
+		if err := bad.WriteDir(dir); err == nil {
+			t.Errorf("Expected an error writing a resource named %q", name)
+		}
+		if _, err := os.Stat(filepath.Join(parent, "escaped.yaml")); err == nil {
+			t.Errorf("Resource named %q was written outside of %s", name, dir)
+		}
+	}
+}